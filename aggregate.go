@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/stenstromen/goipcalc/ipcalc"
+)
+
+var aggregateFile string
+
+var aggregateCmd = &cobra.Command{
+	Use:     "aggregate [options] [<PREFIX>...]",
+	Aliases: []string{"summarize"},
+	Short:   "Merge prefixes into the minimal equivalent list",
+	Long: `aggregate collapses a list of IPv4 and IPv6 prefixes into the minimal
+list covering exactly the same addresses, merging adjacent and
+overlapping prefixes. Prefixes are taken from the arguments, from a
+file given with -f, or from stdin, one per line.`,
+	Example: `  ipcalc aggregate 10.0.0.0/24 10.0.1.0/24
+  ipcalc aggregate -f allowlist.txt
+  cat routes.txt | ipcalc summarize`,
+	RunE: runAggregate,
+}
+
+func init() {
+	aggregateCmd.Flags().StringVarP(&aggregateFile, "file", "f", "", "Read prefixes from file (- for stdin)")
+	rootCmd.AddCommand(aggregateCmd)
+}
+
+func runAggregate(cmd *cobra.Command, args []string) error {
+	lines, err := readInputs(args, aggregateFile)
+	if err != nil {
+		return err
+	}
+
+	var prefixes []ipcalc.Prefix
+	for _, line := range lines {
+		p, err := ipcalc.ParsePrefix(line)
+		if err != nil {
+			return invalid("PREFIX", line, err)
+		}
+		prefixes = append(prefixes, p)
+	}
+
+	result := ipcalc.Aggregate(prefixes)
+
+	if structuredOutput() {
+		return printReport(os.Stdout, newAggregateReport(len(prefixes), result))
+	}
+	for _, p := range result {
+		fmt.Println(p)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+)
+
+type batchResult struct {
+	output []byte
+	err    error
+}
+
+type batchJob struct {
+	line   int
+	fields []string
+	result chan batchResult
+}
+
+// batchFile runs batch on the file at path, or on stdin when path is empty
+// or "-".
+func batchFile(w, errw io.Writer, path string) (bool, error) {
+	var r io.Reader = os.Stdin
+	if path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return false, err
+		}
+		defer f.Close()
+		r = f
+	}
+	return batch(w, errw, r)
+}
+
+// batch runs the calculation for every line of r, each line holding the
+// arguments of one command line. Blank lines and "#" comments are skipped.
+// Lines are calculated concurrently but written to w in input order; a line
+// that fails is reported on errw and does not stop the run. batch reports
+// whether all lines succeeded.
+func batch(w, errw io.Writer, r io.Reader) (bool, error) {
+	workers := runtime.NumCPU()
+	jobs := make(chan batchJob, workers)
+	// pending holds the result channels in input order, bounding how far
+	// the workers may run ahead of the writer.
+	pending := make(chan chan batchResult, workers*64)
+
+	var readErr error
+	go func() {
+		defer close(jobs)
+		defer close(pending)
+		scanner := bufio.NewScanner(r)
+		lineNum := 0
+		for scanner.Scan() {
+			lineNum++
+			line, _, _ := strings.Cut(scanner.Text(), "#")
+			fields := strings.Fields(line)
+			if len(fields) == 0 {
+				continue
+			}
+			result := make(chan batchResult, 1)
+			pending <- result
+			jobs <- batchJob{line: lineNum, fields: fields, result: result}
+		}
+		readErr = scanner.Err()
+	}()
+
+	for range workers {
+		go func() {
+			for job := range jobs {
+				var buf bytes.Buffer
+				err := calculate(&buf, job.fields)
+				if err != nil {
+					err = fmt.Errorf("line %d: %w", job.line, err)
+				}
+				job.result <- batchResult{output: buf.Bytes(), err: err}
+			}
+		}()
+	}
+
+	out := bufio.NewWriter(w)
+	ok := true
+	for result := range pending {
+		res := <-result
+		if res.err != nil {
+			// Keep stdout and stderr interleaved in input order.
+			out.Flush()
+			fmt.Fprintln(errw, res.err)
+			ok = false
+			continue
+		}
+		out.Write(res.output)
+	}
+	if err := out.Flush(); err != nil {
+		return false, err
+	}
+	return ok, readErr
+}
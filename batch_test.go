@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	var input strings.Builder
+	var want bytes.Buffer
+	input.WriteString("# inventory\n\n")
+	for i := range 500 {
+		args := []string{fmt.Sprintf("10.%d.%d.1/%d", i/256, i%256, 16+i%15)}
+		if i%7 == 0 {
+			args = []string{fmt.Sprintf("2001:db8:%x::1", i), "/48", "/50"}
+		}
+		fmt.Fprintf(&input, "%s  # host %d\n", strings.Join(args, " "), i)
+		if err := calculate(&want, args); err != nil {
+			t.Fatalf("calculate(%v): %v", args, err)
+		}
+	}
+	input.WriteString("10.0.0.300/24\n")
+
+	var out, errOut bytes.Buffer
+	ok, err := batch(&out, &errOut, strings.NewReader(input.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Error("batch reported success despite an invalid line")
+	}
+	if out.String() != want.String() {
+		t.Error("batch output differs from sequential calculation")
+	}
+	if got, want := errOut.String(), "line 503: INVALID ADDRESS: 10.0.0.300\n"; got != want {
+		t.Errorf("batch errors = %q, want %q", got, want)
+	}
+}
+
+func TestBatchRejectsArguments(t *testing.T) {
+	defer func() { optBatch, optBatchFile = false, "" }()
+
+	for _, set := range []func(){
+		func() { optBatch = true },
+		func() { optBatchFile = "-" },
+	} {
+		optBatch, optBatchFile = false, ""
+		set()
+		err := runIPCalc(rootCmd, []string{"10.0.0.0/24"})
+		if got := exitCode(err); got != exitUsage {
+			t.Errorf("--batch %q with an argument: %v, exit code %d, want %d", optBatchFile, err, got, exitUsage)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/stenstromen/goipcalc/ipcalc"
+)
+
+var (
+	checkIn        []string
+	checkPrivate   bool
+	checkPublic    bool
+	checkMulticast bool
+	checkValid     bool
+	checkVerbose   bool
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check [options] <ADDRESS>[/<NETMASK>]...",
+	Short: "Test addresses against conditions and report through the exit status",
+	Long: `check tests every given address or network against all of the given
+conditions and prints nothing; the answer is the exit status:
+
+  0  every address satisfies every condition
+  1  at least one address fails a condition
+  2  usage error: unknown flag or no address given
+  3  an address could not be parsed
+  4  a netmask could not be parsed
+  7  any other error, such as failing to write the output
+
+Without any condition, check only tests that the arguments are valid
+addresses or networks.`,
+	Example: `  ipcalc check --in 10.0.0.0/8 10.1.2.3
+  ipcalc check --in 10.0.0.0/8 --in 192.168.0.0/16 172.16.0.1
+  ipcalc check --private 192.168.1.20 && echo internal
+  ipcalc check --valid "$input" || echo "not an address"`,
+	Args: usageArgs(cobra.MinimumNArgs(1)),
+	RunE: runCheck,
+}
+
+func init() {
+	checkCmd.Flags().StringSliceVar(&checkIn, "in", []string{}, "Address lies in one of these networks")
+	checkCmd.Flags().BoolVar(&checkPrivate, "private", false, "Address is RFC 1918 private or IPv6 unique local")
+	checkCmd.Flags().BoolVar(&checkPublic, "public", false, "Address is globally reachable unicast")
+	checkCmd.Flags().BoolVar(&checkMulticast, "multicast", false, "Address is multicast")
+	checkCmd.Flags().BoolVar(&checkValid, "valid", false, "Argument is a valid address or network")
+	checkCmd.Flags().BoolVarP(&checkVerbose, "verbose", "v", false, "Print a yes/no verdict for each address")
+	rootCmd.AddCommand(checkCmd)
+}
+
+func runCheck(cmd *cobra.Command, args []string) error {
+	var networks []ipcalc.Prefix
+	for _, arg := range checkIn {
+		p, err := ipcalc.ParsePrefix(arg)
+		if err != nil {
+			return invalid("NETWORK", arg, err)
+		}
+		networks = append(networks, p)
+	}
+
+	passed := true
+	var results []checkResultReport
+	for _, arg := range args {
+		ok, err := checkAddress(arg, networks)
+		if err != nil && !checkValid {
+			return invalid("ADDRESS", arg, err)
+		}
+		if !ok {
+			passed = false
+		}
+		results = append(results, checkResultReport{Address: arg, Result: ok})
+		if checkVerbose && !structuredOutput() {
+			verdict := "yes"
+			if !ok {
+				verdict = "no"
+			}
+			fmt.Printf("%s: %s\n", arg, verdict)
+		}
+	}
+
+	if structuredOutput() {
+		if err := printReport(os.Stdout, checkReport{Result: passed, Addresses: results}); err != nil {
+			return err
+		}
+	}
+	if !passed {
+		return exitStatus(exitFailure)
+	}
+	return nil
+}
+
+// checkAddress reports whether arg satisfies all requested conditions, or
+// the error parsing it.
+func checkAddress(arg string, networks []ipcalc.Prefix) (bool, error) {
+	p, err := ipcalc.ParsePrefix(arg)
+	if err != nil {
+		return false, err
+	}
+
+	if len(networks) > 0 {
+		in := false
+		for _, n := range networks {
+			if n.Contains(p) {
+				in = true
+				break
+			}
+		}
+		if !in {
+			return false, nil
+		}
+	}
+	if checkPrivate && !p.IsPrivate() {
+		return false, nil
+	}
+	if checkPublic && !p.IsGlobal() {
+		return false, nil
+	}
+	if checkMulticast && !p.IsMulticast() {
+		return false, nil
+	}
+	return true, nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stenstromen/goipcalc/ipcalc"
+)
+
+var coverFile string
+
+var coverCmd = &cobra.Command{
+	Use:   "cover [options] [<ADDRESS>[/<NETMASK>] | <ADDRESS1> - <ADDRESS2>]...",
+	Short: "Find the smallest network covering addresses, networks and ranges",
+	Long: `cover finds the single smallest network that contains all of the given
+addresses, networks and address ranges, and reports how many addresses
+it covers beyond them. The list is taken from the arguments, from a file
+given with -f, or from stdin, one entry per line. All entries must be of
+the same family.`,
+	Example: `  ipcalc cover 10.0.0.17 10.0.1.3 10.0.2.0/25
+  ipcalc cover 192.168.0.100 - 192.168.3.20
+  ipcalc cover -f security-group.txt`,
+	RunE: runCover,
+}
+
+func init() {
+	coverCmd.Flags().StringVarP(&coverFile, "file", "f", "", "Read entries from file (- for stdin)")
+	rootCmd.AddCommand(coverCmd)
+}
+
+func runCover(cmd *cobra.Command, args []string) error {
+	lines, err := readInputs(args, coverFile)
+	if err != nil {
+		return err
+	}
+
+	var fields []string
+	for _, line := range lines {
+		fields = append(fields, strings.Fields(line)...)
+	}
+	if len(fields) == 0 {
+		return usageError{fmt.Errorf("no addresses given")}
+	}
+
+	prefixes, err := parseCoverEntries(fields)
+	if err != nil {
+		return err
+	}
+
+	cover := ipcalc.Cover(prefixes)
+	covered := ipcalc.AddressCount(prefixes)
+
+	if structuredOutput() {
+		return printReport(os.Stdout, newCoverReport(fields, cover, covered))
+	}
+
+	extra := new(big.Int).Sub(cover.Size(), covered)
+	fmt.Printf("%-11s%s\n", "Network:", cover)
+	fmt.Printf("%-11s%s\n", "Size:", cover.Size())
+	fmt.Printf("%-11s%s\n", "Covered:", covered)
+	fmt.Printf("%-11s%s (%.2f%%)\n", "Extra:", extra, extraPercent(extra, cover.Size()))
+	return nil
+}
+
+// parseCoverEntries turns the entries into prefixes. An entry is an address
+// or network, or a range written as "ADDRESS1 - ADDRESS2" or
+// "ADDRESS1-ADDRESS2".
+func parseCoverEntries(fields []string) ([]ipcalc.Prefix, error) {
+	var prefixes []ipcalc.Prefix
+	for i := 0; i < len(fields); i++ {
+		var start, end string
+		var isRange bool
+		switch {
+		case i+2 < len(fields) && fields[i+1] == "-":
+			start, end, isRange = fields[i], fields[i+2], true
+			i += 2
+		case strings.Contains(fields[i], "-"):
+			start, end, _ = strings.Cut(fields[i], "-")
+			isRange = true
+		}
+
+		if !isRange {
+			p, err := ipcalc.ParsePrefix(fields[i])
+			if err != nil {
+				return nil, invalid("ADDRESS", fields[i], err)
+			}
+			prefixes = append(prefixes, p)
+		} else {
+			r, err := parseRange(start, end)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, r...)
+		}
+
+		if last := prefixes[len(prefixes)-1]; last.Bits() != prefixes[0].Bits() {
+			return nil, &inputError{msg: fmt.Sprintf("%s and %s must be of the same family", prefixes[0], last), err: ipcalc.ErrFamilyMismatch}
+		}
+	}
+	return prefixes, nil
+}
+
+// parseRange returns the prefixes exactly covering the range start to end.
+func parseRange(start, end string) ([]ipcalc.Prefix, error) {
+	first, last, err := parseRangeEnds(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return ipcalc.RangePrefixes(first.First(), last.First(), first.Bits()), nil
+}
+
+// parseRangeEnds parses the ends of the range start to end as host
+// prefixes of the same family, start first.
+func parseRangeEnds(start, end string) (ipcalc.Prefix, ipcalc.Prefix, error) {
+	first, last := net.ParseIP(start), net.ParseIP(end)
+	if first == nil {
+		return ipcalc.Prefix{}, ipcalc.Prefix{}, invalid("ADDRESS", start, ipcalc.ErrInvalidAddress)
+	}
+	if last == nil {
+		return ipcalc.Prefix{}, ipcalc.Prefix{}, invalid("ADDRESS2", end, ipcalc.ErrInvalidAddress)
+	}
+	// Both are bare addresses, which ParsePrefix gives the family they are
+	// written in
+	p1, _ := ipcalc.ParsePrefix(start)
+	p2, _ := ipcalc.ParsePrefix(end)
+	if p1.Bits() != p2.Bits() {
+		return ipcalc.Prefix{}, ipcalc.Prefix{}, &inputError{msg: "ADDRESS and ADDRESS2 must be of the same family", err: ipcalc.ErrFamilyMismatch}
+	}
+	if p1.First().Cmp(p2.First()) > 0 {
+		return ipcalc.Prefix{}, ipcalc.Prefix{}, errRangeInverted
+	}
+	return p1, p2, nil
+}
+
+// extraPercent returns extra as a percentage of size.
+func extraPercent(extra, size *big.Int) float64 {
+	f, _ := new(big.Rat).SetFrac(new(big.Int).Mul(extra, big.NewInt(100)), size).Float64()
+	return f
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"iter"
+	"math/big"
+	"net"
+	"slices"
+	"strconv"
+
+	"github.com/stenstromen/goipcalc/ipcalc"
+)
+
+var (
+	optCSV = false
+	optTSV = false
+)
+
+var csvHeader = []string{"Index", "Network", "Prefix", "Netmask", "First Host", "Last Host", "Broadcast", "Hosts", "Description"}
+
+// tableOutput reports whether results are written as CSV or TSV rows.
+func tableOutput() bool {
+	return optCSV || optTSV
+}
+
+func newRowWriter(w io.Writer) *csv.Writer {
+	cw := csv.NewWriter(w)
+	if optTSV {
+		cw.Comma = '\t'
+	}
+	return cw
+}
+
+// printHeader writes the header row on its own, for batch mode where the
+// rows of all lines share it.
+func printHeader(w io.Writer) error {
+	cw := newRowWriter(w)
+	cw.Write(csvHeader)
+	cw.Flush()
+	return cw.Error()
+}
+
+// printRows writes one row per network, as CSV or, with --tsv, tab
+// separated, preceded by the header unless in batch mode. Rows without an
+// index are numbered from 1. Rows are written as they are produced.
+func printRows(w io.Writer, rows iter.Seq[[]string]) error {
+	cw := newRowWriter(w)
+	if !optBatch {
+		cw.Write(csvHeader)
+	}
+	i := 0
+	for row := range rows {
+		i++
+		if len(row) < len(csvHeader) {
+			row = append([]string{strconv.Itoa(i)}, row...)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func networkRow(n ipcalc.Network) []string {
+	broadcast := ""
+	if n.Prefix < 31 {
+		broadcast = ipcalc.Uint32ToIP(n.Broadcast).String()
+	}
+	return []string{
+		ipcalc.Uint32ToIP(n.Network).String(),
+		strconv.Itoa(n.Prefix),
+		ipcalc.Uint32ToIP(n.Netmask).String(),
+		ipcalc.Uint32ToIP(n.HostMin).String(),
+		ipcalc.Uint32ToIP(n.HostMax).String(),
+		broadcast,
+		strconv.FormatUint(n.Hosts, 10),
+		getDescription(n),
+	}
+}
+
+// network6Row lists every address of the prefix as a host, since IPv6 has
+// no broadcast address.
+func network6Row(n ipcalc.Network6) []string {
+	p := ipcalc.Prefix{IP: n.Network.To16(), Len: n.Prefix}
+	return []string{
+		formatIP6(n.Network),
+		strconv.Itoa(n.Prefix),
+		n.Netmask.String(),
+		formatIP6(n.Network),
+		formatIP6(ipcalc.BigIntToIP6(p.Last())),
+		"",
+		p.Size().String(),
+		n.Netblock.Description(),
+	}
+}
+
+// unusedRow marks a row as space left over by a split.
+func unusedRow(row []string) []string {
+	desc := "Unused"
+	if row[len(row)-1] != "" {
+		desc += ", " + row[len(row)-1]
+	}
+	row[len(row)-1] = desc
+	return row
+}
+
+// ipv4Rows returns the networks an IPv4 calculation lists: the split
+// allocations and unused space, the subnets, the supernet, or the network
+// itself. Subnets are calculated as the rows are written.
+func ipv4Rows(address uint32, mask1, mask2 int, split *ipcalc.SplitResult) iter.Seq[[]string] {
+	n := ipcalc.NewNetwork(address, mask1)
+	var rows [][]string
+	switch {
+	case split != nil:
+		for _, a := range split.Allocations {
+			rows = append(rows, networkRow(a.Network))
+		}
+		for _, u := range split.Unused {
+			rows = append(rows, unusedRow(networkRow(u)))
+		}
+	case mask1 < mask2:
+		first, end := subnetWindow4(ipcalc.SubnetCount(mask1, mask2))
+		return func(yield func([]string) bool) {
+			for i := first; i < end; i++ {
+				row := networkRow(ipcalc.Subnet(n.Network, mask2, i))
+				if !yield(append([]string{strconv.FormatUint(i+1, 10)}, row...)) {
+					return
+				}
+			}
+		}
+	case mask1 > mask2:
+		rows = append(rows, networkRow(ipcalc.Supernet(n.Network, mask2)))
+	default:
+		rows = append(rows, networkRow(n))
+	}
+	return slices.Values(rows)
+}
+
+// ipv6Rows is ipv4Rows for IPv6.
+func ipv6Rows(address net.IP, mask1, mask2 int, split *ipcalc.SplitResult6) iter.Seq[[]string] {
+	n := ipcalc.NewNetwork6(address, mask1)
+	var rows [][]string
+	switch {
+	case split != nil:
+		for _, a := range split.Allocations {
+			rows = append(rows, network6Row(a.Network))
+		}
+		for _, u := range split.Unused {
+			rows = append(rows, unusedRow(network6Row(u)))
+		}
+	case mask1 < mask2:
+		first, end := subnetWindow(ipcalc.SubnetCount6(mask1, mask2))
+		return func(yield func([]string) bool) {
+			one := big.NewInt(1)
+			for i := new(big.Int).Set(first); i.Cmp(end) < 0; i.Add(i, one) {
+				row := network6Row(ipcalc.Subnet6(n.Network, mask2, i))
+				if !yield(append([]string{new(big.Int).Add(i, one).String()}, row...)) {
+					return
+				}
+			}
+		}
+	default:
+		rows = append(rows, network6Row(n))
+	}
+	return slices.Values(rows)
+}
+
+func rangeRows(nets []ipcalc.Network) [][]string {
+	var rows [][]string
+	for _, n := range nets {
+		rows = append(rows, networkRow(n))
+	}
+	return rows
+}
+
+func range6Rows(nets []ipcalc.Network6) [][]string {
+	var rows [][]string
+	for _, n := range nets {
+		rows = append(rows, network6Row(n))
+	}
+	return rows
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+func TestCSVOutput(t *testing.T) {
+	defer func() { optCSV, optTSV = false, false }()
+
+	tests := []struct {
+		args  string
+		tsv   bool
+		rows  int
+		check []string
+	}{
+		{"192.168.0.1/24 /26", false, 4, []string{"4", "192.168.0.192", "26", "255.255.255.192", "192.168.0.193", "192.168.0.254", "192.168.0.255", "62", "Class C, Private Internet (src/dst, forwardable, not global)"}},
+		{"10.0.0.1 - 10.0.0.3", true, 2, []string{"2", "10.0.0.2", "31", "255.255.255.254", "10.0.0.2", "10.0.0.3", "", "2", "Class A, Private Internet (src/dst, forwardable, not global), PtP Link RFC 3021"}},
+		{"2001:db8::/48 /49", false, 2, []string{"2", "2001:db8:0:8000::", "49", "ffff:ffff:ffff:8000::", "2001:db8:0:8000::", "2001:db8:0:ffff:ffff:ffff:ffff:ffff", "", "604462909807314587353088", "Documentation (not src/dst, not forwardable, not global)"}},
+		{"::ffff:10.0.0.0/120", false, 1, []string{"1", "::ffff:10.0.0.0", "120", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ff00", "::ffff:10.0.0.0", "::ffff:10.0.0.255", "", "256", "IPv4-mapped (not src/dst, not forwardable, not global, reserved-by-protocol)"}},
+	}
+
+	for _, tt := range tests {
+		optCSV, optTSV = !tt.tsv, tt.tsv
+		var buf bytes.Buffer
+		if err := calculate(&buf, strings.Fields(tt.args)); err != nil {
+			t.Fatalf("calculate(%s): %v", tt.args, err)
+		}
+		r := csv.NewReader(&buf)
+		if tt.tsv {
+			r.Comma = '\t'
+		}
+		records, err := r.ReadAll()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.args, err)
+		}
+		if len(records) != tt.rows+1 || strings.Join(records[0], ",") != strings.Join(csvHeader, ",") {
+			t.Fatalf("%s: got %d records with header %v, want %d rows", tt.args, len(records), records[0], tt.rows)
+		}
+		if got, want := strings.Join(records[len(records)-1], "|"), strings.Join(tt.check, "|"); got != want {
+			t.Errorf("%s: last row = %s, want %s", tt.args, got, want)
+		}
+	}
+}
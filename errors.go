@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/stenstromen/goipcalc/ipcalc"
+)
+
+// Exit statuses, documented in the README.
+const (
+	exitOK             = 0
+	exitFailure        = 1 // a false check answer or overlaps found
+	exitUsage          = 2
+	exitInvalidAddress = 3
+	exitInvalidMask    = 4
+	exitFamilyMismatch = 5
+	exitRangeInverted  = 6
+	exitError          = 7 // a failed batch line, an I/O error or any other error
+)
+
+// errUsage marks errors in how the command was invoked: unknown flags,
+// missing arguments or invalid option values.
+var errUsage = errors.New("usage error")
+
+// exitStatus is returned by commands that have already reported everything
+// they have to say; main exits with it without printing anything.
+type exitStatus int
+
+func (s exitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", int(s))
+}
+
+// inputError is an error in an argument or input line. Its message is meant
+// for the user; the error it wraps decides the exit status.
+type inputError struct {
+	msg string
+	err error
+}
+
+func (e *inputError) Error() string {
+	return e.msg
+}
+
+func (e *inputError) Unwrap() error {
+	return e.err
+}
+
+// invalid returns the error for an argument named label that failed to
+// parse with err, reported as "INVALID LABEL: value".
+func invalid(label, value string, err error) error {
+	return &inputError{msg: fmt.Sprintf("INVALID %s: %s", label, value), err: err}
+}
+
+// usageError wraps err as an errUsage.
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string {
+	return e.err.Error()
+}
+
+func (e usageError) Unwrap() []error {
+	return []error{e.err, errUsage}
+}
+
+// usageArgs wraps a positional argument validator so that its errors are
+// usage errors.
+func usageArgs(args cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, a []string) error {
+		if err := args(cmd, a); err != nil {
+			return usageError{err}
+		}
+		return nil
+	}
+}
+
+// exitCode returns the exit status for an error returned by a command.
+func exitCode(err error) int {
+	var status exitStatus
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &status):
+		return int(status)
+	case errors.Is(err, errUsage), errors.Is(err, ipcalc.ErrInvalidSize):
+		return exitUsage
+	case errors.Is(err, ipcalc.ErrInvalidAddress):
+		return exitInvalidAddress
+	case errors.Is(err, ipcalc.ErrInvalidMask):
+		return exitInvalidMask
+	case errors.Is(err, ipcalc.ErrFamilyMismatch):
+		return exitFamilyMismatch
+	case errors.Is(err, ipcalc.ErrRangeInverted):
+		return exitRangeInverted
+	}
+	return exitError
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCalculateExitCode(t *testing.T) {
+	tests := []struct {
+		args string
+		want int
+	}{
+		{"192.168.0.1/24", exitOK},
+		{"2001:db8::1/48 /52", exitOK},
+		{"10.0.0.1 - 10.0.0.9", exitOK},
+		{"::ffff:192.0.2.33/96", exitOK},
+		{"::ffff:10.0.0.1 - ::ffff:10.0.0.9", exitOK},
+		{"192.168.0.256/24", exitInvalidAddress},
+		{"192.168.0.1/33", exitInvalidMask},
+		{"192.168.0.1 255.0.255.0", exitInvalidMask},
+		{"2001:db8::1/48 /129", exitInvalidMask},
+		{"10.0.0.1 - 2001:db8::1", exitFamilyMismatch},
+		{"::ffff:10.0.0.1 - 10.0.0.9", exitFamilyMismatch},
+		{"10.0.0.9 - 10.0.0.1", exitRangeInverted},
+		{"2001:db8::9 - 2001:db8::1", exitRangeInverted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			err := calculate(io.Discard, strings.Fields(tt.args))
+			if got := exitCode(err); got != tt.want {
+				t.Errorf("calculate(%s) = %v, exit code %d, want %d", tt.args, err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitSizeBound(t *testing.T) {
+	defer func() { optSplitSizes = nil }()
+
+	tests := []struct {
+		network string
+		sizes   string
+		want    int
+	}{
+		{"0.0.0.0/0", "4294967294", exitOK},
+		{"0.0.0.0/0", "4294967295", exitUsage},
+		{"0.0.0.0/0", "4294967296", exitUsage},
+		{"0.0.0.0/0", "2147483646,2147483646", exitOK},
+		{"0.0.0.0/0", "2147483646,2147483647", exitUsage},
+		{"10.0.0.0/24", "254", exitOK},
+		{"10.0.0.0/24", "255", exitUsage},
+		{"255.255.255.0/24", "500,500", exitUsage},
+		{"::/0", "2^128", exitOK},
+		{"::/0", "2^128,2^128", exitUsage},
+		{"2001:db8::/64", "2^64,1", exitUsage},
+	}
+
+	for _, tt := range tests {
+		optSplitSizes = strings.Split(tt.sizes, ",")
+		err := calculate(io.Discard, []string{tt.network})
+		if got := exitCode(err); got != tt.want {
+			t.Errorf("%s -s %s: %v, exit code %d, want %d", tt.network, tt.sizes, err, got, tt.want)
+		}
+	}
+}
+
+func TestExitCodeOtherErrors(t *testing.T) {
+	// Status 1 is kept for false answers; anything else that goes wrong has
+	// its own status
+	_, err := batchFile(io.Discard, io.Discard, filepath.Join(t.TempDir(), "missing"))
+	if got := exitCode(err); got != exitError {
+		t.Errorf("unreadable batch file: %v, exit code %d, want %d", err, got, exitError)
+	}
+	if got := exitCode(exitStatus(exitFailure)); got != exitFailure {
+		t.Errorf("false answer: exit code %d, want %d", got, exitFailure)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/stenstromen/goipcalc/ipcalc"
+)
+
+var excludeFile string
+
+var excludeCmd = &cobra.Command{
+	Use:   "exclude [options] <NETWORK> [<PREFIX>...]",
+	Short: "Remove prefixes from a network and list what is left",
+	Long: `exclude takes a parent network and one or more prefixes to remove from
+it and prints the remaining address space as the minimal list of
+prefixes. The prefixes to remove are taken from the arguments after
+NETWORK, from a file given with -f, or from stdin, one per line.`,
+	Example: `  ipcalc exclude 10.0.0.0/16 10.0.1.0/24 10.0.5.0/24 10.0.255.0/24
+  ipcalc exclude 2001:db8::/32 -f assigned.txt`,
+	Args: usageArgs(cobra.MinimumNArgs(1)),
+	RunE: runExclude,
+}
+
+func init() {
+	excludeCmd.Flags().StringVarP(&excludeFile, "file", "f", "", "Read prefixes to remove from file (- for stdin)")
+	rootCmd.AddCommand(excludeCmd)
+}
+
+func runExclude(cmd *cobra.Command, args []string) error {
+	parent, err := ipcalc.ParsePrefix(args[0])
+	if err != nil {
+		return invalid("NETWORK", args[0], err)
+	}
+
+	lines, err := readInputs(args[1:], excludeFile)
+	if err != nil {
+		return err
+	}
+
+	var excluded []ipcalc.Prefix
+	for _, line := range lines {
+		p, err := ipcalc.ParsePrefix(line)
+		if err != nil {
+			return invalid("PREFIX", line, err)
+		}
+		if p.Bits() != parent.Bits() {
+			return &inputError{msg: fmt.Sprintf("%s and %s must be of the same family", parent, p), err: ipcalc.ErrFamilyMismatch}
+		}
+		excluded = append(excluded, p)
+	}
+
+	result := ipcalc.Exclude(parent, excluded)
+
+	if structuredOutput() {
+		return printReport(os.Stdout, newExcludeReport(parent, excluded, result))
+	}
+	for _, p := range result {
+		fmt.Println(p)
+	}
+	return nil
+}
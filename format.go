@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	optFormat   string
+	optTemplate *template.Template
+)
+
+// parseFormat parses the template given with --format: the value itself when
+// it contains an action, otherwise the contents of the file it names.
+func parseFormat(format string) (*template.Template, error) {
+	text := format
+	if !strings.Contains(format, "{{") {
+		data, err := os.ReadFile(format)
+		if err != nil {
+			return nil, usageError{fmt.Errorf("--format is neither a template nor a readable file: %w", err)}
+		}
+		text = string(data)
+	}
+	t, err := template.New("format").Parse(text)
+	if err != nil {
+		return nil, usageError{err}
+	}
+	return t, nil
+}
+
+// preRunFormat loads the --format template before any command runs.
+func preRunFormat(cmd *cobra.Command, args []string) error {
+	if optFormat == "" {
+		return nil
+	}
+	t, err := parseFormat(optFormat)
+	if err != nil {
+		return err
+	}
+	optTemplate = t
+	return nil
+}
+
+// structuredOutput reports whether results are written as data, with --json
+// or --format, instead of the text layout.
+func structuredOutput() bool {
+	return optJSON || optTemplate != nil
+}
+
+// lastByteWriter remembers the last byte written through it.
+type lastByteWriter struct {
+	w       io.Writer
+	last    byte
+	written bool
+}
+
+func (lw *lastByteWriter) Write(p []byte) (int, error) {
+	n, err := lw.w.Write(p)
+	if n > 0 {
+		lw.last, lw.written = p[n-1], true
+	}
+	return n, err
+}
+
+// printReport writes a report through the --format template if one was
+// given, otherwise as JSON. Template output is written as it is executed
+// and always ends in a newline.
+func printReport(w io.Writer, v any) error {
+	if optTemplate == nil {
+		return printJSON(w, v)
+	}
+	lw := &lastByteWriter{w: w}
+	if err := optTemplate.Execute(lw, v); err != nil {
+		return err
+	}
+	if lw.written && lw.last != '\n' {
+		_, err := io.WriteString(w, "\n")
+		return err
+	}
+	return nil
+}
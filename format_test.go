@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stenstromen/goipcalc/ipcalc"
+)
+
+func TestParseFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dhcpd.tmpl")
+	content := "{{range .Subnets.Networks}}subnet {{.Network}} netmask {{.Netmask}}\n{{end}}"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	report := newIPv4Report(ipcalc.IPToUint32(net.ParseIP("10.0.0.1")), 24, 25, nil)
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"{{.Network.HostMin}}-{{.Network.HostMax}}", "10.0.0.1-10.0.0.254\n"},
+		{"{{.Network.Network}}/{{.Prefix}} {{.Network.Description}}\n", "10.0.0.0/24 Class A, Private Internet (src/dst, forwardable, not global)\n"},
+		{path, "subnet 10.0.0.0 netmask 255.255.255.128\nsubnet 10.0.0.128 netmask 255.255.255.128\n"},
+	}
+
+	defer func() { optTemplate = nil }()
+	for _, tt := range tests {
+		tmpl, err := parseFormat(tt.format)
+		if err != nil {
+			t.Fatalf("parseFormat(%q): %v", tt.format, err)
+		}
+		optTemplate = tmpl
+		var buf bytes.Buffer
+		if err := printReport(&buf, report); err != nil {
+			t.Fatalf("printReport(%q): %v", tt.format, err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("printReport(%q) = %q, want %q", tt.format, buf.String(), tt.want)
+		}
+	}
+
+	for _, format := range []string{"{{.Network", filepath.Join(t.TempDir(), "missing")} {
+		if _, err := parseFormat(format); !errors.Is(err, errUsage) {
+			t.Errorf("parseFormat(%q) error = %v, want a usage error", format, err)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+)
+
+// readInputs returns the arguments if any were given, otherwise the lines of
+// the file at path, or of stdin when path is empty or "-". Blank lines and
+// "#" comments are dropped.
+func readInputs(args []string, path string) ([]string, error) {
+	if len(args) > 0 {
+		return args, nil
+	}
+
+	var r io.Reader = os.Stdin
+	if path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadInputs(t *testing.T) {
+	args, err := readInputs([]string{"10.0.0.0/8", "2001:db8::/32"}, "ignored")
+	if err != nil || strings.Join(args, " ") != "10.0.0.0/8 2001:db8::/32" {
+		t.Errorf("readInputs(args) = %v, %v", args, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "prefixes.txt")
+	content := "# allowlist\n10.0.0.0/24\n\n  10.0.1.0/24  # office\n2001:db8::/48\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lines, err := readInputs(nil, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(lines, ","), "10.0.0.0/24,10.0.1.0/24,2001:db8::/48"; got != want {
+		t.Errorf("readInputs(file) = %s, want %s", got, want)
+	}
+
+	if _, err := readInputs(nil, filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("readInputs(missing file) returned no error")
+	}
+}
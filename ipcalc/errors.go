@@ -0,0 +1,13 @@
+package ipcalc
+
+import "errors"
+
+// Errors returned, possibly wrapped, by the parsing functions. Callers
+// distinguish them with errors.Is.
+var (
+	ErrInvalidAddress = errors.New("invalid address")
+	ErrInvalidMask    = errors.New("invalid netmask")
+	ErrInvalidSize    = errors.New("invalid size")
+	ErrFamilyMismatch = errors.New("address family mismatch")
+	ErrRangeInverted  = errors.New("range ends before it starts")
+)
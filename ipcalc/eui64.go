@@ -0,0 +1,74 @@
+package ipcalc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"net"
+)
+
+// EUI64 returns the address in the /64 of prefix whose interface identifier
+// is the modified EUI-64 of mac (RFC 4291 appendix A): a 48-bit MAC has
+// ff:fe inserted in its middle, and the universal/local bit is inverted.
+func EUI64(prefix net.IP, mac net.HardwareAddr) (net.IP, error) {
+	var iid []byte
+	switch len(mac) {
+	case 6:
+		iid = []byte{mac[0], mac[1], mac[2], 0xff, 0xfe, mac[3], mac[4], mac[5]}
+	case 8:
+		iid = append([]byte{}, mac...)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidAddress, mac)
+	}
+	iid[0] ^= 0x02
+	return withInterfaceID(prefix, iid), nil
+}
+
+// MACFromEUI64 returns the MAC address a modified EUI-64 interface
+// identifier was made from, or false if the identifier of ip does not have
+// the ff:fe in its middle.
+func MACFromEUI64(ip net.IP) (net.HardwareAddr, bool) {
+	ip16 := ip.To16()
+	if ip16 == nil || ip.To4() != nil || ip16[11] != 0xff || ip16[12] != 0xfe {
+		return nil, false
+	}
+	return net.HardwareAddr{ip16[8] ^ 0x02, ip16[9], ip16[10], ip16[13], ip16[14], ip16[15]}, true
+}
+
+// StablePrivacy returns the address in the /64 of prefix with the RFC 7217
+// semantically opaque interface identifier for the interface, network and
+// DAD counter, keyed by secret. RFC 7217 leaves the pseudorandom function
+// to the implementation; this uses SHA-256 over the prefix, interface name,
+// network ID, DAD counter and secret, in that order, and takes the first 64
+// bits. Identifiers reserved by RFC 5453 are skipped by counting the DAD
+// counter up, as the RFC prescribes.
+func StablePrivacy(prefix net.IP, iface, networkID string, dadCounter uint32, secret []byte) net.IP {
+	for ; ; dadCounter++ {
+		h := sha256.New()
+		h.Write(prefix.To16()[:8])
+		h.Write([]byte(iface))
+		h.Write([]byte(networkID))
+		binary.Write(h, binary.BigEndian, dadCounter)
+		h.Write(secret)
+		if iid := h.Sum(nil)[:8]; !reservedInterfaceID(iid) {
+			return withInterfaceID(prefix, iid)
+		}
+	}
+}
+
+// reservedInterfaceID reports whether iid is one of the interface
+// identifiers reserved by RFC 5453: the subnet-router anycast identifier,
+// the range used by the IANA Ethernet block and the subnet anycast range.
+func reservedInterfaceID(iid []byte) bool {
+	return bytes.Equal(iid, make([]byte, 8)) ||
+		bytes.HasPrefix(iid, []byte{0x02, 0x00, 0x5e, 0xff, 0xfe}) ||
+		bytes.Equal(iid[:7], []byte{0xfd, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}) && iid[7] >= 0x80
+}
+
+func withInterfaceID(prefix net.IP, iid []byte) net.IP {
+	ip := make(net.IP, net.IPv6len)
+	copy(ip, prefix.To16()[:8])
+	copy(ip[8:], iid)
+	return ip
+}
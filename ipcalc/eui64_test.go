@@ -0,0 +1,83 @@
+package ipcalc
+
+import (
+	"net"
+	"testing"
+)
+
+func TestEUI64(t *testing.T) {
+	tests := []struct {
+		mac      string
+		expected string
+		wantErr  bool
+	}{
+		{"00:11:22:33:44:55", "2001:db8::211:22ff:fe33:4455", false},
+		{"02:00:00:00:00:01", "2001:db8::ff:fe00:1", false},
+		{"00:11:22:33:44:55:66:77", "2001:db8::211:2233:4455:6677", false},
+	}
+
+	prefix := net.ParseIP("2001:db8::")
+	for _, tt := range tests {
+		mac, err := net.ParseMAC(tt.mac)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ip, err := EUI64(prefix, mac)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("EUI64(%s) error = %v", tt.mac, err)
+		}
+		if got := ip.String(); got != tt.expected {
+			t.Errorf("EUI64(%s) = %s, want %s", tt.mac, got, tt.expected)
+		}
+	}
+}
+
+func TestMACFromEUI64(t *testing.T) {
+	mac, ok := MACFromEUI64(net.ParseIP("fde6:36fc:c985:0:c2c1:c0ff:fe1d:cc7f"))
+	if !ok || mac.String() != "c0:c1:c0:1d:cc:7f" {
+		t.Errorf("MACFromEUI64 = %s, %v, want c0:c1:c0:1d:cc:7f", mac, ok)
+	}
+	if _, ok := MACFromEUI64(net.ParseIP("2001:db8::1")); ok {
+		t.Error("MACFromEUI64(2001:db8::1) found a MAC")
+	}
+}
+
+func TestStablePrivacy(t *testing.T) {
+	prefix := net.ParseIP("2001:db8:1:2::")
+	a := StablePrivacy(prefix, "eth0", "", 0, []byte("secret"))
+	if !a.Mask(net.CIDRMask(64, 128)).Equal(prefix) {
+		t.Errorf("StablePrivacy = %s, outside %s/64", a, prefix)
+	}
+	if b := StablePrivacy(prefix, "eth0", "", 0, []byte("secret")); !a.Equal(b) {
+		t.Errorf("StablePrivacy is not stable: %s, %s", a, b)
+	}
+	for _, b := range []net.IP{
+		StablePrivacy(prefix, "eth1", "", 0, []byte("secret")),
+		StablePrivacy(prefix, "eth0", "", 1, []byte("secret")),
+		StablePrivacy(prefix, "eth0", "", 0, []byte("other")),
+		StablePrivacy(net.ParseIP("2001:db8:1:3::"), "eth0", "", 0, []byte("secret")),
+	} {
+		if a[8:].Equal(b[8:]) {
+			t.Errorf("StablePrivacy gave %s and %s the same identifier", a, b)
+		}
+	}
+}
+
+func TestReservedInterfaceID(t *testing.T) {
+	tests := []struct {
+		iid      []byte
+		expected bool
+	}{
+		{[]byte{0, 0, 0, 0, 0, 0, 0, 0}, true},
+		{[]byte{0x02, 0x00, 0x5e, 0xff, 0xfe, 0x00, 0x52, 0x13}, true},
+		{[]byte{0xfd, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x80}, true},
+		{[]byte{0xfd, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}, false},
+		{[]byte{0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0}, false},
+	}
+
+	for _, tt := range tests {
+		if got := reservedInterfaceID(tt.iid); got != tt.expected {
+			t.Errorf("reservedInterfaceID(%x) = %v, want %v", tt.iid, got, tt.expected)
+		}
+	}
+}
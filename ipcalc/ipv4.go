@@ -0,0 +1,135 @@
+// Package ipcalc implements the address arithmetic behind the ipcalc command:
+// netmask parsing, network/broadcast/host range calculation, classful
+// information, subnetting, supernetting, splitting and deaggregation.
+//
+// Functions in this package return values and never print; presentation is
+// left to the caller.
+package ipcalc
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+var classBits = []int{0, 8, 16, 24, 4, 5, 5}
+
+// IPToUint32 converts an IPv4 address to its 32-bit integer form.
+func IPToUint32(ip net.IP) uint32 {
+	ip = ip.To4()
+	return uint32(ip[0])<<24 | uint32(ip[1])<<16 | uint32(ip[2])<<8 | uint32(ip[3])
+}
+
+// Uint32ToIP converts a 32-bit integer to an IPv4 address.
+func Uint32ToIP(n uint32) net.IP {
+	return net.IP{byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}
+}
+
+// CIDRToMask returns the netmask for a prefix length, or 0 if it is out of range.
+func CIDRToMask(cidr int) uint32 {
+	if cidr < 0 || cidr > 32 {
+		return 0
+	}
+	return ^(uint32(1)<<(32-cidr) - 1)
+}
+
+// MaskToCIDR returns the number of leading one bits in mask.
+func MaskToCIDR(mask uint32) int {
+	cidr := 0
+	for i := 0; i < 32; i++ {
+		if (mask & (uint32(1) << (31 - i))) != 0 {
+			cidr++
+		} else {
+			break
+		}
+	}
+	return cidr
+}
+
+// ParseNetmask accepts a prefix length ("24" or "/24"), a dotted decimal
+// netmask ("255.255.255.0") or a wildcard mask ("0.0.0.255") and returns the
+// prefix length.
+func ParseNetmask(arg string) (int, error) {
+	// Remove leading slash if present
+	arg = strings.TrimPrefix(arg, "/")
+
+	// Try CIDR notation (e.g., "24" or "/24")
+	if cidr, err := strconv.Atoi(arg); err == nil {
+		if cidr >= 0 && cidr <= 32 {
+			return cidr, nil
+		}
+		return 0, fmt.Errorf("%w: %s", ErrInvalidMask, arg)
+	}
+
+	// Try dotted decimal notation (e.g., "255.255.255.0")
+	if ip := net.ParseIP(arg); ip != nil {
+		ip = ip.To4()
+		if ip != nil {
+			mask := IPToUint32(ip)
+			// Check if it's a valid netmask
+			if IsValidNetmask(mask) {
+				return MaskToCIDR(mask), nil
+			}
+			// Try wildcard mask
+			mask = ^mask
+			if IsValidNetmask(mask) {
+				return MaskToCIDR(mask), nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("%w: %s", ErrInvalidMask, arg)
+}
+
+// IsValidNetmask reports whether mask consists of contiguous leading one bits.
+func IsValidNetmask(mask uint32) bool {
+	sawZero := false
+	for i := 0; i < 32; i++ {
+		bit := (mask >> (31 - i)) & 1
+		if bit == 0 {
+			sawZero = true
+		} else {
+			if sawZero {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Class returns the classful network class ("A" to "E") of ip.
+func Class(ip net.IP) string {
+	ip = ip.To4()
+	n := IPToUint32(ip)
+	class := 1
+	for class <= 5 {
+		if (n & (uint32(1) << (32 - class))) == (uint32(1) << (32 - class)) {
+			class++
+		} else {
+			break
+		}
+	}
+	if class > 5 {
+		return "invalid"
+	}
+	return string(rune(class + 64))
+}
+
+// ClassBits returns the classful prefix length of ip.
+func ClassBits(ip net.IP) int {
+	ip = ip.To4()
+	n := IPToUint32(ip)
+	class := 1
+	for class <= 5 {
+		if (n & (uint32(1) << (32 - class))) == (uint32(1) << (32 - class)) {
+			class++
+		} else {
+			break
+		}
+	}
+	if class > 5 {
+		return 0
+	}
+	return classBits[class]
+}
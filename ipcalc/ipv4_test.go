@@ -1,6 +1,7 @@
-package main
+package ipcalc
 
 import (
+	"errors"
 	"net"
 	"testing"
 )
@@ -23,9 +24,9 @@ func TestIPToUint32(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ip := net.ParseIP(tt.ip).To4()
-			result := ipToUint32(ip)
+			result := IPToUint32(ip)
 			if result != tt.expected {
-				t.Errorf("ipToUint32(%s) = 0x%08X, want 0x%08X", tt.ip, result, tt.expected)
+				t.Errorf("IPToUint32(%s) = 0x%08X, want 0x%08X", tt.ip, result, tt.expected)
 			}
 		})
 	}
@@ -48,9 +49,9 @@ func TestUint32ToIP(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := uint32ToIP(tt.value)
+			result := Uint32ToIP(tt.value)
 			if result.String() != tt.expected {
-				t.Errorf("uint32ToIP(0x%08X) = %s, want %s", tt.value, result.String(), tt.expected)
+				t.Errorf("Uint32ToIP(0x%08X) = %s, want %s", tt.value, result.String(), tt.expected)
 			}
 		})
 	}
@@ -71,8 +72,8 @@ func TestIPRoundTrip(t *testing.T) {
 	for _, ipStr := range tests {
 		t.Run(ipStr, func(t *testing.T) {
 			ip := net.ParseIP(ipStr).To4()
-			value := ipToUint32(ip)
-			result := uint32ToIP(value)
+			value := IPToUint32(ip)
+			result := Uint32ToIP(value)
 			if !ip.Equal(result) {
 				t.Errorf("Round trip failed: %s -> 0x%08X -> %s", ipStr, value, result.String())
 			}
@@ -100,9 +101,9 @@ func TestCIDRToMask(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := cidrToMask(tt.cidr)
+			result := CIDRToMask(tt.cidr)
 			if result != tt.expected {
-				t.Errorf("cidrToMask(%d) = 0x%08X, want 0x%08X", tt.cidr, result, tt.expected)
+				t.Errorf("CIDRToMask(%d) = 0x%08X, want 0x%08X", tt.cidr, result, tt.expected)
 			}
 		})
 	}
@@ -127,9 +128,9 @@ func TestMaskToCIDR(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := maskToCIDR(tt.mask)
+			result := MaskToCIDR(tt.mask)
 			if result != tt.expected {
-				t.Errorf("maskToCIDR(0x%08X) = %d, want %d", tt.mask, result, tt.expected)
+				t.Errorf("MaskToCIDR(0x%08X) = %d, want %d", tt.mask, result, tt.expected)
 			}
 		})
 	}
@@ -138,8 +139,8 @@ func TestMaskToCIDR(t *testing.T) {
 func TestCIDRMaskRoundTrip(t *testing.T) {
 	for cidr := 0; cidr <= 32; cidr++ {
 		t.Run(string(rune(cidr+'0')), func(t *testing.T) {
-			mask := cidrToMask(cidr)
-			result := maskToCIDR(mask)
+			mask := CIDRToMask(cidr)
+			result := MaskToCIDR(mask)
 			if result != cidr {
 				t.Errorf("Round trip failed: CIDR %d -> mask 0x%08X -> CIDR %d", cidr, mask, result)
 			}
@@ -168,9 +169,9 @@ func TestIsValidNetmask(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := isValidNetmask(tt.mask)
+			result := IsValidNetmask(tt.mask)
 			if result != tt.expected {
-				t.Errorf("isValidNetmask(0x%08X) = %v, want %v", tt.mask, result, tt.expected)
+				t.Errorf("IsValidNetmask(0x%08X) = %v, want %v", tt.mask, result, tt.expected)
 			}
 		})
 	}
@@ -216,19 +217,22 @@ func TestParseNetmask(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := parseNetmask(tt.input)
+			result, err := ParseNetmask(tt.input)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("parseNetmask(%s) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+				t.Errorf("ParseNetmask(%s) error = %v, wantErr %v", tt.input, err, tt.wantErr)
 				return
 			}
+			if tt.wantErr && !errors.Is(err, ErrInvalidMask) {
+				t.Errorf("ParseNetmask(%s) error = %v, want ErrInvalidMask", tt.input, err)
+			}
 			if !tt.wantErr && result != tt.expected {
-				t.Errorf("parseNetmask(%s) = %d, want %d", tt.input, result, tt.expected)
+				t.Errorf("ParseNetmask(%s) = %d, want %d", tt.input, result, tt.expected)
 			}
 		})
 	}
 }
 
-func TestGetClass(t *testing.T) {
+func TestClass(t *testing.T) {
 	tests := []struct {
 		name     string
 		ip       string
@@ -247,15 +251,15 @@ func TestGetClass(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ip := net.ParseIP(tt.ip).To4()
-			result := getClass(ip)
+			result := Class(ip)
 			if result != tt.expected {
-				t.Errorf("getClass(%s) = %s, want %s", tt.ip, result, tt.expected)
+				t.Errorf("Class(%s) = %s, want %s", tt.ip, result, tt.expected)
 			}
 		})
 	}
 }
 
-func TestGetClassBits(t *testing.T) {
+func TestClassBits(t *testing.T) {
 	tests := []struct {
 		name     string
 		ip       string
@@ -274,11 +278,10 @@ func TestGetClassBits(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ip := net.ParseIP(tt.ip).To4()
-			result := getClassBits(ip)
+			result := ClassBits(ip)
 			if result != tt.expected {
-				t.Errorf("getClassBits(%s) = %d, want %d", tt.ip, result, tt.expected)
+				t.Errorf("ClassBits(%s) = %d, want %d", tt.ip, result, tt.expected)
 			}
 		})
 	}
 }
-
@@ -0,0 +1,156 @@
+package ipcalc
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Network6 is an IPv6 address combined with a prefix length.
+type Network6 struct {
+	Address  net.IP
+	Prefix   int
+	Netmask  net.IP
+	Network  net.IP
+	Netblock Netblock
+}
+
+// NewNetwork6 calculates the prefix that address belongs to at the given
+// prefix length.
+func NewNetwork6(address net.IP, prefix int) Network6 {
+	network := address.Mask(net.CIDRMask(prefix, 128))
+	return Network6{
+		Address:  address,
+		Prefix:   prefix,
+		Netmask:  PrefixLenToN6(prefix),
+		Network:  network,
+		Netblock: FindNetblock6(network, prefix),
+	}
+}
+
+// PrefixLenToN6 returns the IPv6 netmask for a prefix length.
+func PrefixLenToN6(prefixLen int) net.IP {
+	n := big.NewInt(0)
+	for i := 127; i > 127-prefixLen; i-- {
+		n.Or(n, big.NewInt(0).Lsh(big.NewInt(1), uint(i)))
+	}
+	return BigIntToIP6(n)
+}
+
+// BigIntToIP6 converts a 128-bit integer to an IPv6 address. It returns nil
+// if n is negative or wider than 128 bits.
+func BigIntToIP6(n *big.Int) net.IP {
+	if n.Sign() < 0 || n.BitLen() > 128 {
+		return nil
+	}
+	ip := make(net.IP, 16)
+	bytes := n.Bytes()
+	copy(ip[16-len(bytes):], bytes)
+	return ip
+}
+
+// IP6ToBigInt converts an IPv6 address to its 128-bit integer form.
+func IP6ToBigInt(ip net.IP) *big.Int {
+	return new(big.Int).SetBytes(ip.To16())
+}
+
+// ParsePrefixLen6 accepts an IPv6 prefix length with or without a leading
+// slash.
+func ParsePrefixLen6(arg string) (int, error) {
+	prefix, err := strconv.Atoi(strings.TrimPrefix(arg, "/"))
+	if err != nil || prefix < 0 || prefix > 128 {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidMask, arg)
+	}
+	return prefix, nil
+}
+
+// SubnetCount6 returns the number of /mask2 subnets in a /mask1 IPv6 prefix.
+func SubnetCount6(mask1, mask2 int) *big.Int {
+	if mask2 < mask1 {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Lsh(big.NewInt(1), uint(mask2-mask1))
+}
+
+// Subnet6 returns the i-th (zero based) /mask2 subnet of network.
+func Subnet6(network net.IP, mask2 int, i *big.Int) Network6 {
+	n := new(big.Int).Lsh(i, uint(128-mask2))
+	n.Or(n, IP6ToBigInt(network))
+	return NewNetwork6(BigIntToIP6(n), mask2)
+}
+
+// Deaggregate6 returns the minimal list of prefixes that exactly covers the
+// IPv6 address range start to end.
+func Deaggregate6(start, end net.IP) []Network6 {
+	var nets []Network6
+	for _, p := range RangePrefixes(IP6ToBigInt(start), IP6ToBigInt(end), 128) {
+		nets = append(nets, NewNetwork6(p.IP, p.Len))
+	}
+	return nets
+}
+
+// Allocation6 is one prefix handed out by Split6.
+type Allocation6 struct {
+	Requested *big.Int
+	Network   Network6
+}
+
+// SplitResult6 is the outcome of splitting an IPv6 prefix into subnets.
+type SplitResult6 struct {
+	Allocations []Allocation6
+	Needed      *big.Int
+	Used        Network6
+	Unused      []Network6
+}
+
+// Split6 allocates prefixes large enough to hold the requested numbers of
+// addresses from the given IPv6 prefix, largest first, and reports the space
+// left over. Sizes larger than 2^128 are treated as 2^128. It returns
+// ErrInvalidSize if the allocations together do not fit in the prefix.
+func Split6(network net.IP, prefix int, sizes []*big.Int) (SplitResult6, error) {
+	one := big.NewInt(1)
+	result := SplitResult6{Needed: big.NewInt(0)}
+	for _, size := range sizes {
+		bitLen := 0
+		if size.Cmp(one) > 0 {
+			bitLen = min(new(big.Int).Sub(size, one).BitLen(), 128)
+		}
+		result.Allocations = append(result.Allocations, Allocation6{
+			Requested: size,
+			Network:   Network6{Prefix: 128 - bitLen},
+		})
+		result.Needed.Add(result.Needed, new(big.Int).Lsh(one, uint(bitLen)))
+	}
+
+	space := new(big.Int).Lsh(one, uint(128-prefix))
+	if result.Needed.Cmp(space) > 0 {
+		return SplitResult6{}, fmt.Errorf("%w: %s addresses needed, more than the /%d network holds", ErrInvalidSize, result.Needed, prefix)
+	}
+
+	sort.SliceStable(result.Allocations, func(i, j int) bool {
+		return result.Allocations[i].Network.Prefix < result.Allocations[j].Network.Prefix
+	})
+
+	base := IP6ToBigInt(NewNetwork6(network, prefix).Network)
+	end := new(big.Int).Add(base, space)
+	current := new(big.Int).Set(base)
+	for i := 0; i < len(result.Allocations) && current.Cmp(end) < 0; i++ {
+		a := &result.Allocations[i]
+		a.Network = NewNetwork6(BigIntToIP6(current), a.Network.Prefix)
+		current.Add(current, new(big.Int).Lsh(one, uint(128-a.Network.Prefix)))
+	}
+
+	usedPrefix := 128
+	if result.Needed.Cmp(one) > 0 {
+		usedPrefix = 128 - new(big.Int).Sub(result.Needed, one).BitLen()
+	}
+	result.Used = NewNetwork6(network, usedPrefix)
+
+	if current.Cmp(end) < 0 {
+		result.Unused = Deaggregate6(BigIntToIP6(current), BigIntToIP6(lastAddress6(base, prefix)))
+	}
+	return result, nil
+}
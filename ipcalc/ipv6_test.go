@@ -0,0 +1,258 @@
+package ipcalc
+
+import (
+	"errors"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPrefixLenToN6(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   int
+		validate func(net.IP) bool
+	}{
+		{"Prefix /0", 0, func(ip net.IP) bool {
+			return ip.Equal(net.ParseIP("::"))
+		}},
+		{"Prefix /64", 64, func(ip net.IP) bool {
+			// Should have first 64 bits set
+			ip16 := ip.To16()
+			for i := 0; i < 8; i++ {
+				if ip16[i] != 0xFF {
+					return false
+				}
+			}
+			for i := 8; i < 16; i++ {
+				if ip16[i] != 0x00 {
+					return false
+				}
+			}
+			return true
+		}},
+		{"Prefix /128", 128, func(ip net.IP) bool {
+			// All bits should be set
+			ip16 := ip.To16()
+			for i := 0; i < 16; i++ {
+				if ip16[i] != 0xFF {
+					return false
+				}
+			}
+			return true
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := PrefixLenToN6(tt.prefix)
+			if result == nil {
+				t.Errorf("PrefixLenToN6(%d) returned nil", tt.prefix)
+				return
+			}
+			if !tt.validate(result) {
+				t.Errorf("PrefixLenToN6(%d) = %s, validation failed", tt.prefix, result.String())
+			}
+		})
+	}
+}
+
+func TestBigIntToIP6(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string // hexadecimal
+		expected string // "" for nil
+	}{
+		{"Zero", "0", "::"},
+		{"One", "1", "::1"},
+		{"Max", "ffffffffffffffffffffffffffffffff", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"},
+		{"Negative", "-1", ""},
+		{"Too wide", "100000000000000000000000000000000", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, _ := new(big.Int).SetString(tt.value, 16)
+			ip := BigIntToIP6(n)
+			if tt.expected == "" {
+				if ip != nil {
+					t.Errorf("BigIntToIP6(%s) = %s, want nil", tt.value, ip)
+				}
+				return
+			}
+			if ip.String() != tt.expected {
+				t.Errorf("BigIntToIP6(%s) = %s, want %s", tt.value, ip, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIPv6AddressMasking(t *testing.T) {
+	tests := []struct {
+		name   string
+		ip     string
+		mask   int
+		result string
+	}{
+		{"/64 mask", "2001:0db8:85a3:0000:0000:8a2e:0370:7334", 64, "2001:db8:85a3::"},
+		{"/48 mask", "2001:0db8:85a3:0000:0000:8a2e:0370:7334", 48, "2001:db8:85a3::"},
+		{"/128 mask", "2001:0db8:85a3:0000:0000:8a2e:0370:7334", 128, "2001:db8:85a3::8a2e:370:7334"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip := net.ParseIP(tt.ip)
+			if ip == nil {
+				t.Fatalf("Failed to parse IP: %s", tt.ip)
+			}
+			prefix := ip.Mask(net.CIDRMask(tt.mask, 128))
+			expected := net.ParseIP(tt.result)
+			if expected == nil {
+				t.Fatalf("Failed to parse expected IP: %s", tt.result)
+			}
+			if !prefix.Equal(expected) {
+				t.Errorf("Masking %s with /%d = %s, want %s", tt.ip, tt.mask, prefix.String(), tt.result)
+			}
+		})
+	}
+}
+
+func TestParsePrefixLen6(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+		wantErr  bool
+	}{
+		{"64", 64, false},
+		{"/56", 56, false},
+		{"0", 0, false},
+		{"128", 128, false},
+		{"129", 0, true},
+		{"-1", 0, true},
+		{"ffff::", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := ParsePrefixLen6(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePrefixLen6(%s) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrInvalidMask) {
+				t.Errorf("ParsePrefixLen6(%s) error = %v, want ErrInvalidMask", tt.input, err)
+			}
+			if result != tt.expected {
+				t.Errorf("ParsePrefixLen6(%s) = %d, want %d", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSubnet6(t *testing.T) {
+	if got := SubnetCount6(48, 56).String(); got != "256" {
+		t.Errorf("SubnetCount6(48, 56) = %s, want 256", got)
+	}
+	if got := SubnetCount6(0, 128).String(); got != "340282366920938463463374607431768211456" {
+		t.Errorf("SubnetCount6(0, 128) = %s, want 2^128", got)
+	}
+
+	network := net.ParseIP("2001:db8::")
+	tests := []struct {
+		index    int64
+		expected string
+	}{
+		{0, "2001:db8::"},
+		{1, "2001:db8:0:100::"},
+		{255, "2001:db8:0:ff00::"},
+	}
+	for _, tt := range tests {
+		n := Subnet6(network, 56, big.NewInt(tt.index))
+		if !n.Network.Equal(net.ParseIP(tt.expected)) || n.Prefix != 56 {
+			t.Errorf("Subnet6(2001:db8::, 56, %d) = %s/%d, want %s/56", tt.index, n.Network, n.Prefix, tt.expected)
+		}
+	}
+}
+
+func TestDeaggregate6(t *testing.T) {
+	tests := []struct {
+		start    string
+		end      string
+		expected []string
+	}{
+		{"2001:db8::", "2001:db8::", []string{"2001:db8::/128"}},
+		{"2001:db8::", "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff", []string{"2001:db8::/32"}},
+		{"2001:db8::5", "2001:db8::1:ff", []string{
+			"2001:db8::5/128", "2001:db8::6/127", "2001:db8::8/125", "2001:db8::10/124",
+			"2001:db8::20/123", "2001:db8::40/122", "2001:db8::80/121", "2001:db8::100/120",
+			"2001:db8::200/119", "2001:db8::400/118", "2001:db8::800/117", "2001:db8::1000/116",
+			"2001:db8::2000/115", "2001:db8::4000/114", "2001:db8::8000/113", "2001:db8::1:0/120",
+		}},
+		{"::", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", []string{"::/0"}},
+		{"2001:db8::2", "2001:db8::1", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.start+"-"+tt.end, func(t *testing.T) {
+			var result []string
+			for _, n := range Deaggregate6(net.ParseIP(tt.start), net.ParseIP(tt.end)) {
+				result = append(result, n.Network.String()+"/"+strconv.Itoa(n.Prefix))
+			}
+			if strings.Join(result, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("Deaggregate6(%s, %s) = %v, want %v", tt.start, tt.end, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSplit6(t *testing.T) {
+	sizes := []*big.Int{big.NewInt(1024), big.NewInt(1 << 20), new(big.Int).Lsh(big.NewInt(1), 64)}
+	result, err := Split6(net.ParseIP("2001:db8::"), 48, sizes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"2001:db8::/64", "2001:db8:0:1::/108", "2001:db8:0:1::10:0/118"}
+	for i, a := range result.Allocations {
+		if got := a.Network.Network.String() + "/" + strconv.Itoa(a.Network.Prefix); got != expected[i] {
+			t.Errorf("Allocation %d = %s, want %s", i, got, expected[i])
+		}
+	}
+	if result.Allocations[0].Requested.Cmp(sizes[2]) != 0 {
+		t.Errorf("Allocation 0 requested %s, want %s", result.Allocations[0].Requested, sizes[2])
+	}
+	if result.Needed.String() != "18446744073710601216" {
+		t.Errorf("Needed = %s, want 18446744073710601216", result.Needed)
+	}
+	if result.Used.Prefix != 63 {
+		t.Errorf("Used = /%d, want /63", result.Used.Prefix)
+	}
+	last := result.Unused[len(result.Unused)-1]
+	if got := last.Network.String() + "/" + strconv.Itoa(last.Prefix); got != "2001:db8:0:8000::/49" {
+		t.Errorf("last unused = %s, want 2001:db8:0:8000::/49", got)
+	}
+
+	result, err = Split6(net.ParseIP("2001:db8::"), 126, []*big.Int{big.NewInt(4)})
+	if err != nil || len(result.Unused) != 0 {
+		t.Errorf("Split6 /126 into 4 addresses: Unused = %v, %v", result.Unused, err)
+	}
+
+	// Requests that overflow the parent prefix, up to the whole address
+	// space, are rejected
+	two128 := new(big.Int).Lsh(big.NewInt(1), 128)
+	overflows := []struct {
+		network string
+		prefix  int
+		sizes   []*big.Int
+	}{
+		{"2001:db8::", 126, []*big.Int{big.NewInt(8)}},
+		{"2001:db8::", 64, []*big.Int{new(big.Int).Lsh(big.NewInt(1), 64), big.NewInt(1)}},
+		{"::", 0, []*big.Int{two128, two128}},
+	}
+	for _, tt := range overflows {
+		if _, err := Split6(net.ParseIP(tt.network), tt.prefix, tt.sizes); !errors.Is(err, ErrInvalidSize) {
+			t.Errorf("Split6(%s/%d, %v) error = %v, want ErrInvalidSize", tt.network, tt.prefix, tt.sizes, err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package ipcalc
+
+import (
+	"fmt"
+	"net"
+)
+
+// Prefixes IPv4 addresses are embedded in without further configuration.
+var (
+	NAT64WellKnown = mustParsePrefix("64:ff9b::/96")
+	IPv4Mapped     = Prefix{IP: net.ParseIP("::ffff:0:0"), Len: 96}
+)
+
+// embeddedIPv4Bytes returns the positions of the four bytes of an IPv4
+// address embedded behind a prefix of the given length (RFC 6052 section
+// 2.2). Bits 64 to 71, the u-octet, are always skipped.
+func embeddedIPv4Bytes(length int) ([]int, error) {
+	switch length {
+	case 32, 40, 48, 56, 64, 96:
+	default:
+		return nil, fmt.Errorf("%w: /%d, NAT64 prefixes are /32, /40, /48, /56, /64 or /96", ErrInvalidMask, length)
+	}
+	var positions []int
+	for i := length / 8; len(positions) < 4; i++ {
+		if i != 8 {
+			positions = append(positions, i)
+		}
+	}
+	return positions, nil
+}
+
+// EmbedIPv4 returns the IPv4-embedded IPv6 address of v4 under prefix, as
+// a NAT64 translator or DNS64 server synthesizes it.
+func EmbedIPv4(prefix Prefix, v4 net.IP) (net.IP, error) {
+	positions, err := embeddedIPv4Bytes(prefix.Len)
+	if err != nil {
+		return nil, err
+	}
+	if prefix.Is4() {
+		return nil, fmt.Errorf("%w: %s is not an IPv6 prefix", ErrFamilyMismatch, prefix)
+	}
+	v4bytes := v4.To4()
+	if v4bytes == nil {
+		return nil, fmt.Errorf("%w: %s is not an IPv4 address", ErrFamilyMismatch, v4)
+	}
+
+	ip := make(net.IP, net.IPv6len)
+	copy(ip, prefix.IP)
+	for i, pos := range positions {
+		ip[pos] = v4bytes[i]
+	}
+	return ip, nil
+}
+
+// ExtractIPv4 returns the IPv4 address embedded in ip under prefix. It
+// does not check that ip lies in prefix.
+func ExtractIPv4(prefix Prefix, ip net.IP) (net.IP, error) {
+	positions, err := embeddedIPv4Bytes(prefix.Len)
+	if err != nil {
+		return nil, err
+	}
+	ip16 := ip.To16()
+	v4 := make(net.IP, net.IPv4len)
+	for i, pos := range positions {
+		v4[i] = ip16[pos]
+	}
+	return v4, nil
+}
+
+// EmbeddedIPv4 returns the IPv4 address embedded in ip and the prefix it
+// is embedded under, if ip lies in the NAT64 well-known prefix or is an
+// IPv4-mapped address.
+func EmbeddedIPv4(ip net.IP) (net.IP, Prefix, bool) {
+	host := Prefix{IP: ip.To16(), Len: 128}
+	for _, p := range []Prefix{NAT64WellKnown, IPv4Mapped} {
+		if p.Contains(host) {
+			v4, _ := ExtractIPv4(p, ip)
+			return v4, p, true
+		}
+	}
+	return nil, Prefix{}, false
+}
@@ -0,0 +1,66 @@
+package ipcalc
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestEmbedIPv4(t *testing.T) {
+	// The examples of RFC 6052 section 2.4
+	tests := []struct {
+		prefix   string
+		expected string
+	}{
+		{"2001:db8::/32", "2001:db8:c000:221::"},
+		{"2001:db8:100::/40", "2001:db8:1c0:2:21::"},
+		{"2001:db8:122::/48", "2001:db8:122:c000:2:2100::"},
+		{"2001:db8:122:300::/56", "2001:db8:122:3c0:0:221::"},
+		{"2001:db8:122:344::/64", "2001:db8:122:344:c0:2:2100:0"},
+		{"2001:db8:122:344::/96", "2001:db8:122:344::c000:221"},
+		{"64:ff9b::/96", "64:ff9b::c000:221"},
+	}
+
+	v4 := net.ParseIP("192.0.2.33")
+	for _, tt := range tests {
+		p := mustParsePrefix(tt.prefix)
+		ip, err := EmbedIPv4(p, v4)
+		if err != nil {
+			t.Fatalf("EmbedIPv4(%s): %v", tt.prefix, err)
+		}
+		if got := ip.String(); got != tt.expected {
+			t.Errorf("EmbedIPv4(%s) = %s, want %s", tt.prefix, got, tt.expected)
+		}
+		back, err := ExtractIPv4(p, ip)
+		if err != nil || !back.Equal(v4) {
+			t.Errorf("ExtractIPv4(%s, %s) = %s, %v", tt.prefix, ip, back, err)
+		}
+	}
+
+	if _, err := EmbedIPv4(mustParsePrefix("2001:db8::/33"), v4); !errors.Is(err, ErrInvalidMask) {
+		t.Errorf("EmbedIPv4 under a /33: %v, want ErrInvalidMask", err)
+	}
+}
+
+func TestEmbeddedIPv4(t *testing.T) {
+	tests := []struct {
+		ip       string
+		expected string
+		prefix   Prefix
+	}{
+		{"64:ff9b::c000:221", "192.0.2.33", NAT64WellKnown},
+		{"::ffff:192.0.2.33", "192.0.2.33", IPv4Mapped},
+		{"2001:db8::c000:221", "", Prefix{}},
+	}
+
+	for _, tt := range tests {
+		v4, p, ok := EmbeddedIPv4(net.ParseIP(tt.ip))
+		if ok != (tt.expected != "") {
+			t.Errorf("EmbeddedIPv4(%s) found = %v", tt.ip, ok)
+			continue
+		}
+		if ok && (v4.String() != tt.expected || p.String() != tt.prefix.String()) {
+			t.Errorf("EmbeddedIPv4(%s) = %s in %s, want %s in %s", tt.ip, v4, p, tt.expected, tt.prefix)
+		}
+	}
+}
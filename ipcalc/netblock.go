@@ -0,0 +1,240 @@
+package ipcalc
+
+import (
+	"math/big"
+	"net"
+	"strings"
+)
+
+// Netblock is a well-known address block a network falls into.
+type Netblock struct {
+	Name       string
+	URL        string
+	Partial    bool
+	Attributes *Attributes
+}
+
+// Attributes are the properties the IANA special-purpose registries record
+// for a block (RFC 6890).
+type Attributes struct {
+	Source             bool `json:"source"`
+	Destination        bool `json:"destination"`
+	Forwardable        bool `json:"forwardable"`
+	GloballyReachable  bool `json:"globally_reachable"`
+	ReservedByProtocol bool `json:"reserved_by_protocol"`
+}
+
+func (b Netblock) String() string {
+	if b.Partial {
+		return "In Part " + b.Name
+	}
+	return b.Name
+}
+
+// Description returns the block name followed by its attributes, if the
+// network lies entirely in the block and the block has any.
+func (b Netblock) Description() string {
+	if b.Partial || b.Attributes == nil {
+		return b.String()
+	}
+	return b.Name + " (" + b.Attributes.String() + ")"
+}
+
+func (a Attributes) String() string {
+	var attrs []string
+	switch {
+	case a.Source && a.Destination:
+		attrs = append(attrs, "src/dst")
+	case a.Source:
+		attrs = append(attrs, "src only")
+	case a.Destination:
+		attrs = append(attrs, "dst only")
+	default:
+		attrs = append(attrs, "not src/dst")
+	}
+	if a.Forwardable {
+		attrs = append(attrs, "forwardable")
+	} else {
+		attrs = append(attrs, "not forwardable")
+	}
+	if a.GloballyReachable {
+		attrs = append(attrs, "global")
+	} else {
+		attrs = append(attrs, "not global")
+	}
+	if a.ReservedByProtocol {
+		attrs = append(attrs, "reserved-by-protocol")
+	}
+	return strings.Join(attrs, ", ")
+}
+
+type netblockEntry struct {
+	prefix string
+	name   string
+	url    string
+	attrs  *Attributes
+}
+
+// attrs builds Attributes from the registry columns Source, Destination,
+// Forwardable, Globally Reachable and Reserved-by-Protocol. "N/A" columns
+// are recorded as false.
+func attrs(src, dst, fwd, global, reserved bool) *Attributes {
+	return &Attributes{src, dst, fwd, global, reserved}
+}
+
+// netblocks follows the IANA IPv4 Special-Purpose Address Registry, plus
+// multicast. The historic ipcalc names are kept for the blocks it always
+// knew about.
+var netblocks = []netblockEntry{
+	{"0.0.0.0/8", "This network", "http://www.ietf.org/rfc/rfc791.txt", attrs(true, false, false, false, true)},
+	{"0.0.0.0/32", "This host on this network", "http://www.ietf.org/rfc/rfc1122.txt", attrs(true, false, false, false, true)},
+	{"10.0.0.0/8", "Private Internet", "http://www.ietf.org/rfc/rfc1918.txt", attrs(true, true, true, false, false)},
+	{"100.64.0.0/10", "Shared Address Space", "http://www.ietf.org/rfc/rfc6598.txt", attrs(true, true, true, false, false)},
+	{"127.0.0.0/8", "Loopback", "http://www.ietf.org/rfc/rfc1122.txt", attrs(false, false, false, false, true)},
+	{"169.254.0.0/16", "APIPA", "http://www.ietf.org/rfc/rfc3927.txt", attrs(true, true, false, false, true)},
+	{"172.16.0.0/12", "Private Internet", "http://www.ietf.org/rfc/rfc1918.txt", attrs(true, true, true, false, false)},
+	{"192.0.0.0/24", "IETF Protocol Assignments", "http://www.ietf.org/rfc/rfc6890.txt", attrs(false, false, false, false, false)},
+	{"192.0.0.0/29", "IPv4 Service Continuity Prefix", "http://www.ietf.org/rfc/rfc7335.txt", attrs(true, true, true, false, false)},
+	{"192.0.0.8/32", "IPv4 dummy address", "http://www.ietf.org/rfc/rfc7600.txt", attrs(true, false, false, false, false)},
+	{"192.0.0.9/32", "Port Control Protocol Anycast", "http://www.ietf.org/rfc/rfc7723.txt", attrs(true, true, true, true, false)},
+	{"192.0.0.10/32", "TURN Anycast", "http://www.ietf.org/rfc/rfc8155.txt", attrs(true, true, true, true, false)},
+	{"192.0.0.170/32", "NAT64/DNS64 Discovery", "http://www.ietf.org/rfc/rfc8880.txt", attrs(false, false, false, false, true)},
+	{"192.0.0.171/32", "NAT64/DNS64 Discovery", "http://www.ietf.org/rfc/rfc8880.txt", attrs(false, false, false, false, true)},
+	{"192.0.2.0/24", "Documentation (TEST-NET-1)", "http://www.ietf.org/rfc/rfc5737.txt", attrs(false, false, false, false, false)},
+	{"192.31.196.0/24", "AS112-v4", "http://www.ietf.org/rfc/rfc7535.txt", attrs(true, true, true, true, false)},
+	{"192.52.193.0/24", "AMT", "http://www.ietf.org/rfc/rfc7450.txt", attrs(true, true, true, true, false)},
+	{"192.88.99.0/24", "Deprecated (6to4 Relay Anycast)", "http://www.ietf.org/rfc/rfc7526.txt", attrs(false, false, false, false, false)},
+	{"192.168.0.0/16", "Private Internet", "http://www.ietf.org/rfc/rfc1918.txt", attrs(true, true, true, false, false)},
+	{"192.175.48.0/24", "Direct Delegation AS112 Service", "http://www.ietf.org/rfc/rfc7534.txt", attrs(true, true, true, true, false)},
+	{"198.18.0.0/15", "Benchmarking", "http://www.ietf.org/rfc/rfc2544.txt", attrs(true, true, true, false, false)},
+	{"198.51.100.0/24", "Documentation (TEST-NET-2)", "http://www.ietf.org/rfc/rfc5737.txt", attrs(false, false, false, false, false)},
+	{"203.0.113.0/24", "Documentation (TEST-NET-3)", "http://www.ietf.org/rfc/rfc5737.txt", attrs(false, false, false, false, false)},
+	{"224.0.0.0/4", "Multicast", "http://www.ietf.org/rfc/rfc5771.txt", nil},
+	{"240.0.0.0/4", "Reserved", "http://www.ietf.org/rfc/rfc1112.txt", attrs(false, false, false, false, true)},
+	{"255.255.255.255/32", "Limited Broadcast", "http://www.ietf.org/rfc/rfc919.txt", attrs(false, true, false, false, true)},
+}
+
+// netblocks6 follows the IANA IPv6 Special-Purpose Address Registry, plus the
+// multicast and global unicast ranges from the IPv6 Address Space registry.
+var netblocks6 = []netblockEntry{
+	{"::1/128", "Loopback", "http://www.ietf.org/rfc/rfc4291.txt", attrs(false, false, false, false, true)},
+	{"::/128", "Unspecified", "http://www.ietf.org/rfc/rfc4291.txt", attrs(true, false, false, false, true)},
+	{"::ffff:0:0/96", "IPv4-mapped", "http://www.ietf.org/rfc/rfc4291.txt", attrs(false, false, false, false, true)},
+	{"64:ff9b::/96", "NAT64 Well-Known Prefix", "http://www.ietf.org/rfc/rfc6052.txt", attrs(true, true, true, true, false)},
+	{"64:ff9b:1::/48", "NAT64 Local-Use", "http://www.ietf.org/rfc/rfc8215.txt", attrs(true, true, true, false, false)},
+	{"100::/64", "Discard-Only", "http://www.ietf.org/rfc/rfc6666.txt", attrs(true, true, true, false, false)},
+	{"100:0:0:1::/64", "Dummy Prefix", "http://www.ietf.org/rfc/rfc9780.txt", attrs(true, true, false, false, false)},
+	{"2001::/23", "IETF Protocol Assignments", "http://www.ietf.org/rfc/rfc2928.txt", attrs(false, false, false, false, false)},
+	{"2001::/32", "Teredo", "http://www.ietf.org/rfc/rfc4380.txt", attrs(true, true, true, false, false)},
+	{"2001:1::1/128", "Port Control Protocol Anycast", "http://www.ietf.org/rfc/rfc7723.txt", attrs(true, true, true, true, false)},
+	{"2001:1::2/128", "TURN Anycast", "http://www.ietf.org/rfc/rfc8155.txt", attrs(true, true, true, true, false)},
+	{"2001:1::3/128", "DNS-SD SRP Anycast", "http://www.ietf.org/rfc/rfc9665.txt", attrs(true, true, true, true, false)},
+	{"2001:2::/48", "Benchmarking", "http://www.ietf.org/rfc/rfc5180.txt", attrs(true, true, true, false, false)},
+	{"2001:3::/32", "AMT", "http://www.ietf.org/rfc/rfc7450.txt", attrs(true, true, true, true, false)},
+	{"2001:4:112::/48", "AS112-v6", "http://www.ietf.org/rfc/rfc7535.txt", attrs(true, true, true, true, false)},
+	{"2001:10::/28", "Deprecated ORCHID", "http://www.ietf.org/rfc/rfc4843.txt", attrs(false, false, false, false, false)},
+	{"2001:20::/28", "ORCHIDv2", "http://www.ietf.org/rfc/rfc7343.txt", attrs(true, true, true, true, false)},
+	{"2001:30::/28", "Drone Remote ID DETs", "http://www.ietf.org/rfc/rfc9374.txt", attrs(true, true, true, true, false)},
+	{"2001:db8::/32", "Documentation", "http://www.ietf.org/rfc/rfc3849.txt", attrs(false, false, false, false, false)},
+	{"2002::/16", "6to4", "http://www.ietf.org/rfc/rfc3056.txt", attrs(true, true, true, false, false)},
+	{"2620:4f:8000::/48", "Direct Delegation AS112", "http://www.ietf.org/rfc/rfc7534.txt", attrs(true, true, true, true, false)},
+	{"3fff::/20", "Documentation", "http://www.ietf.org/rfc/rfc9637.txt", attrs(false, false, false, false, false)},
+	{"5f00::/16", "Segment Routing SIDs", "http://www.ietf.org/rfc/rfc9602.txt", attrs(true, true, true, false, false)},
+	{"fc00::/7", "Unique-Local", "http://www.ietf.org/rfc/rfc4193.txt", attrs(true, true, true, false, false)},
+	{"fe80::/10", "Link-Local Unicast", "http://www.ietf.org/rfc/rfc4291.txt", attrs(true, true, false, false, true)},
+	{"ff00::/8", "Multicast", "http://www.ietf.org/rfc/rfc4291.txt", nil},
+	{"2000::/3", "Global Unicast", "http://www.ietf.org/rfc/rfc4291.txt", attrs(true, true, true, true, false)},
+}
+
+type netblockRange struct {
+	netblock   Netblock
+	prefix     int
+	start, end *big.Int
+}
+
+func parseNetblocks(entries []netblockEntry) []netblockRange {
+	var ranges []netblockRange
+	for _, block := range entries {
+		_, blockNet, _ := net.ParseCIDR(block.prefix)
+		prefix, bits := blockNet.Mask.Size()
+		start := new(big.Int).SetBytes(blockNet.IP)
+		ranges = append(ranges, netblockRange{
+			netblock: Netblock{Name: block.name, URL: block.url, Attributes: block.attrs},
+			prefix:   prefix,
+			start:    start,
+			end:      lastAddress(start, prefix, bits),
+		})
+	}
+	return ranges
+}
+
+var (
+	netblockRanges  = parseNetblocks(netblocks)
+	netblockRanges6 = parseNetblocks(netblocks6)
+)
+
+// FindNetblock returns the most specific well-known block that the
+// network/mask lies in. If no block contains it entirely, a network that
+// takes in blocks is reported as partly in the largest of them, provided
+// that one holds all the others. The zero Netblock is returned if there is
+// none, as for 0.0.0.0/0, which takes in every block.
+func FindNetblock(network, mask uint32) Netblock {
+	broadcast := network | (^mask)
+	return findNetblock(netblockRanges, big.NewInt(int64(network)), big.NewInt(int64(broadcast)))
+}
+
+// FindNetblock6 is FindNetblock for IPv6 prefixes.
+func FindNetblock6(network net.IP, prefix int) Netblock {
+	start := IP6ToBigInt(network.Mask(net.CIDRMask(prefix, 128)))
+	return findNetblock(netblockRanges6, start, lastAddress6(start, prefix))
+}
+
+func findNetblock(ranges []netblockRange, start, end *big.Int) Netblock {
+	var best Netblock
+	bestPrefix := -1
+	var outer *netblockRange
+	var partStart, partEnd *big.Int
+	for i, block := range ranges {
+		match := 0
+		if start.Cmp(block.start) >= 0 && start.Cmp(block.end) <= 0 {
+			match++
+		}
+		if end.Cmp(block.start) >= 0 && end.Cmp(block.end) <= 0 {
+			match++
+		}
+		if block.start.Cmp(start) > 0 && block.end.Cmp(end) < 0 {
+			match = 1
+		}
+
+		switch {
+		case match == 2 && block.prefix > bestPrefix:
+			best = block.netblock
+			bestPrefix = block.prefix
+		case match == 1:
+			if outer == nil || block.prefix < outer.prefix {
+				outer = &ranges[i]
+			}
+			if partStart == nil || block.start.Cmp(partStart) < 0 {
+				partStart = block.start
+			}
+			if partEnd == nil || block.end.Cmp(partEnd) > 0 {
+				partEnd = block.end
+			}
+		}
+	}
+	if bestPrefix < 0 && outer != nil && outer.start.Cmp(partStart) == 0 && outer.end.Cmp(partEnd) == 0 {
+		best = outer.netblock
+		best.Partial = true
+	}
+	return best
+}
+
+func lastAddress(network *big.Int, prefix, bits int) *big.Int {
+	one := big.NewInt(1)
+	last := new(big.Int).Lsh(one, uint(bits-prefix))
+	return last.Sub(last, one).Or(last, network)
+}
+
+func lastAddress6(network *big.Int, prefix int) *big.Int {
+	return lastAddress(network, prefix, 128)
+}
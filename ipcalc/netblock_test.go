@@ -0,0 +1,119 @@
+package ipcalc
+
+import (
+	"net"
+	"testing"
+)
+
+func TestFindNetblock(t *testing.T) {
+	tests := []struct {
+		name     string
+		network  string
+		prefix   int
+		expected string
+	}{
+		{"Private /24", "192.168.1.0", 24, "Private Internet"},
+		{"Private partial", "10.0.0.0", 7, "In Part Private Internet"},
+		{"Loopback", "127.0.0.1", 32, "Loopback"},
+		{"Public", "8.8.8.0", 24, ""},
+		{"Shared Address Space", "100.64.1.0", 24, "Shared Address Space"},
+		{"TEST-NET-1", "192.0.2.0", 24, "Documentation (TEST-NET-1)"},
+		{"TEST-NET-2", "198.51.100.128", 25, "Documentation (TEST-NET-2)"},
+		{"TEST-NET-3", "203.0.113.7", 32, "Documentation (TEST-NET-3)"},
+		{"Benchmarking", "198.19.0.0", 16, "Benchmarking"},
+		{"Reserved", "250.0.0.0", 8, "Reserved"},
+		{"Limited Broadcast", "255.255.255.255", 32, "Limited Broadcast"},
+		{"This network", "0.1.0.0", 16, "This network"},
+		{"This host most specific", "0.0.0.0", 32, "This host on this network"},
+		{"PCP anycast most specific", "192.0.0.9", 32, "Port Control Protocol Anycast"},
+		{"IETF block", "192.0.0.128", 25, "IETF Protocol Assignments"},
+		{"Full beats partial", "192.0.0.0", 24, "IETF Protocol Assignments"},
+		{"Largest partial", "192.0.0.0", 23, "In Part IETF Protocol Assignments"},
+		{"Partial in separate blocks", "192.0.0.0", 16, ""},
+		{"Everything", "0.0.0.0", 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mask := CIDRToMask(tt.prefix)
+			network := IPToUint32(net.ParseIP(tt.network)) & mask
+			result := FindNetblock(network, mask).String()
+			if result != tt.expected {
+				t.Errorf("FindNetblock(%s/%d) = %q, want %q", tt.network, tt.prefix, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFindNetblock6(t *testing.T) {
+	tests := []struct {
+		name     string
+		network  string
+		prefix   int
+		expected string
+	}{
+		{"Unique-Local", "fd12:3456::", 48, "Unique-Local"},
+		{"Link-Local", "fe80::", 64, "Link-Local Unicast"},
+		{"Documentation", "2001:db8:1::", 48, "Documentation"},
+		{"Documentation 3fff", "3fff:1::", 32, "Documentation"},
+		{"6to4", "2002:c000:204::", 48, "6to4"},
+		{"Teredo", "2001:0:4136:e378::", 64, "Teredo"},
+		{"NAT64", "64:ff9b::c000:221", 128, "NAT64 Well-Known Prefix"},
+		{"Multicast", "ff02::1", 128, "Multicast"},
+		{"Loopback", "::1", 128, "Loopback"},
+		{"Unspecified", "::", 128, "Unspecified"},
+		{"Discard-Only", "100::", 64, "Discard-Only"},
+		{"ORCHIDv2", "2001:20::", 28, "ORCHIDv2"},
+		{"Most specific", "2001:1::1", 128, "Port Control Protocol Anycast"},
+		{"IETF block", "2001:1::", 48, "IETF Protocol Assignments"},
+		{"Global Unicast", "2a00:1450::", 32, "Global Unicast"},
+		{"In Part", "4000::", 3, "In Part Segment Routing SIDs"},
+		{"Full beats partial", "2001::", 23, "IETF Protocol Assignments"},
+		{"Unassigned", "4000::", 4, ""},
+		{"Everything", "::", 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := FindNetblock6(net.ParseIP(tt.network), tt.prefix).String()
+			if result != tt.expected {
+				t.Errorf("FindNetblock6(%s/%d) = %q, want %q", tt.network, tt.prefix, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFindNetblockDeterministic(t *testing.T) {
+	mask := CIDRToMask(8)
+	network := IPToUint32(net.ParseIP("192.0.0.0")) & mask
+	first := FindNetblock(network, mask)
+	for i := 0; i < 100; i++ {
+		if got := FindNetblock(network, mask); got.String() != first.String() {
+			t.Fatalf("FindNetblock(192.0.0.0/8) = %q, earlier %q", got, first)
+		}
+	}
+}
+
+func TestNetblockDescription(t *testing.T) {
+	tests := []struct {
+		name     string
+		block    Netblock
+		expected string
+	}{
+		{"Private", FindNetblock(IPToUint32(net.ParseIP("10.0.0.0")), CIDRToMask(8)), "Private Internet (src/dst, forwardable, not global)"},
+		{"Loopback", FindNetblock(IPToUint32(net.ParseIP("127.0.0.0")), CIDRToMask(8)), "Loopback (not src/dst, not forwardable, not global, reserved-by-protocol)"},
+		{"Limited Broadcast", FindNetblock(IPToUint32(net.ParseIP("255.255.255.255")), CIDRToMask(32)), "Limited Broadcast (dst only, not forwardable, not global, reserved-by-protocol)"},
+		{"Multicast has no attributes", FindNetblock(IPToUint32(net.ParseIP("224.0.0.0")), CIDRToMask(24)), "Multicast"},
+		{"Partial has no attributes", FindNetblock(IPToUint32(net.ParseIP("10.0.0.0")), CIDRToMask(7)), "In Part Private Internet"},
+		{"IPv6 Unique-Local", FindNetblock6(net.ParseIP("fd00::"), 8), "Unique-Local (src/dst, forwardable, not global)"},
+		{"None", Netblock{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.block.Description(); got != tt.expected {
+				t.Errorf("Description() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
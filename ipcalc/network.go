@@ -0,0 +1,52 @@
+package ipcalc
+
+// Network is an IPv4 address combined with a prefix length, with everything
+// ipcalc derives from it.
+type Network struct {
+	Address   uint32
+	Prefix    int
+	Netmask   uint32
+	Wildcard  uint32
+	Network   uint32
+	Broadcast uint32
+	HostMin   uint32
+	HostMax   uint32
+	Hosts     uint64
+	Class     string
+	Netblock  Netblock
+}
+
+// NewNetwork calculates the network that address belongs to at the given
+// prefix length.
+func NewNetwork(address uint32, prefix int) Network {
+	mask := CIDRToMask(prefix)
+	network := address & mask
+	broadcast := network | (^mask)
+
+	n := Network{
+		Address:   address,
+		Prefix:    prefix,
+		Netmask:   mask,
+		Wildcard:  ^mask,
+		Network:   network,
+		Broadcast: broadcast,
+		HostMin:   network + 1,
+		HostMax:   broadcast - 1,
+		Class:     Class(Uint32ToIP(network)),
+		Netblock:  FindNetblock(network, mask),
+	}
+	n.Hosts = uint64(n.HostMax-n.HostMin) + 1
+
+	if prefix == 31 {
+		n.HostMin = network
+		n.HostMax = broadcast
+		n.Hosts = 2
+	}
+	if prefix == 32 {
+		n.HostMin = network
+		n.HostMax = network
+		n.Hosts = 1
+	}
+
+	return n
+}
@@ -0,0 +1,50 @@
+package ipcalc
+
+import (
+	"net"
+	"testing"
+)
+
+func TestNewNetwork(t *testing.T) {
+	tests := []struct {
+		name      string
+		address   string
+		prefix    int
+		network   string
+		broadcast string
+		hostMin   string
+		hostMax   string
+		hosts     uint64
+		class     string
+	}{
+		{"Class C /24", "192.168.1.1", 24, "192.168.1.0", "192.168.1.255", "192.168.1.1", "192.168.1.254", 254, "C"},
+		{"Class A /8", "10.1.2.3", 8, "10.0.0.0", "10.255.255.255", "10.0.0.1", "10.255.255.254", 16777214, "A"},
+		{"PtP /31", "192.168.1.5", 31, "192.168.1.4", "192.168.1.5", "192.168.1.4", "192.168.1.5", 2, "C"},
+		{"Hostroute /32", "8.8.8.8", 32, "8.8.8.8", "8.8.8.8", "8.8.8.8", "8.8.8.8", 1, "A"},
+		{"Everything /0", "1.2.3.4", 0, "0.0.0.0", "255.255.255.255", "0.0.0.1", "255.255.255.254", 4294967294, "A"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := NewNetwork(IPToUint32(net.ParseIP(tt.address)), tt.prefix)
+			check := func(field string, got uint32, want string) {
+				if Uint32ToIP(got).String() != want {
+					t.Errorf("%s = %s, want %s", field, Uint32ToIP(got), want)
+				}
+			}
+			check("Network", n.Network, tt.network)
+			check("Broadcast", n.Broadcast, tt.broadcast)
+			check("HostMin", n.HostMin, tt.hostMin)
+			check("HostMax", n.HostMax, tt.hostMax)
+			if n.Hosts != tt.hosts {
+				t.Errorf("Hosts = %d, want %d", n.Hosts, tt.hosts)
+			}
+			if n.Class != tt.class {
+				t.Errorf("Class = %s, want %s", n.Class, tt.class)
+			}
+			if n.Wildcard != ^n.Netmask {
+				t.Errorf("Wildcard = 0x%08X, want 0x%08X", n.Wildcard, ^n.Netmask)
+			}
+		})
+	}
+}
@@ -0,0 +1,344 @@
+package ipcalc
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// Prefix is an IPv4 or IPv6 network in CIDR notation. IP holds the network
+// address, 4 bytes long for IPv4 and 16 for IPv6.
+type Prefix struct {
+	IP  net.IP
+	Len int
+}
+
+// ParsePrefix parses ADDRESS, ADDRESS/PREFIXLEN or, for IPv4,
+// ADDRESS/NETMASK. A bare address is a host prefix. Host bits are cleared.
+// An address written with colons is IPv6, including IPv4-mapped addresses
+// such as ::ffff:10.0.0.0.
+func ParsePrefix(s string) (Prefix, error) {
+	addr, mask, hasMask := strings.Cut(strings.TrimSpace(s), "/")
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return Prefix{}, fmt.Errorf("%w: %s", ErrInvalidAddress, addr)
+	}
+
+	if ip4 := ip.To4(); ip4 != nil && !strings.Contains(addr, ":") {
+		length := 32
+		if hasMask {
+			l, err := ParseNetmask(mask)
+			if err != nil {
+				return Prefix{}, err
+			}
+			length = l
+		}
+		return NewPrefix(ip4, length), nil
+	}
+
+	length := 128
+	if hasMask {
+		l, err := ParsePrefixLen6(mask)
+		if err != nil {
+			return Prefix{}, err
+		}
+		length = l
+	}
+	return Prefix{IP: ip.To16().Mask(net.CIDRMask(length, 128)), Len: length}, nil
+}
+
+// NewPrefix returns the prefix of the given length that ip lies in. An
+// IPv4-mapped ip gives an IPv4 prefix, as net.IP does not tell the two
+// apart.
+func NewPrefix(ip net.IP, length int) Prefix {
+	if ip4 := ip.To4(); ip4 != nil {
+		return Prefix{IP: ip4.Mask(net.CIDRMask(length, 32)), Len: length}
+	}
+	return Prefix{IP: ip.To16().Mask(net.CIDRMask(length, 128)), Len: length}
+}
+
+// String returns the prefix in CIDR notation. IPv4-mapped IPv6 prefixes
+// keep their ::ffff: form.
+func (p Prefix) String() string {
+	if !p.Is4() {
+		return fmt.Sprintf("%s/%d", netip.AddrFrom16([16]byte(p.IP)), p.Len)
+	}
+	return fmt.Sprintf("%s/%d", p.IP, p.Len)
+}
+
+// Bits returns the address length of the prefix family, 32 or 128.
+func (p Prefix) Bits() int {
+	return len(p.IP) * 8
+}
+
+// Is4 reports whether p is an IPv4 prefix.
+func (p Prefix) Is4() bool {
+	return len(p.IP) == net.IPv4len
+}
+
+// First returns the first address of the prefix as an integer.
+func (p Prefix) First() *big.Int {
+	return new(big.Int).SetBytes(p.IP)
+}
+
+// Last returns the last address of the prefix as an integer.
+func (p Prefix) Last() *big.Int {
+	return lastAddress(p.First(), p.Len, p.Bits())
+}
+
+// Size returns the number of addresses in the prefix.
+func (p Prefix) Size() *big.Int {
+	return new(big.Int).Lsh(big.NewInt(1), uint(p.Bits()-p.Len))
+}
+
+// Contains reports whether q lies entirely within p.
+func (p Prefix) Contains(q Prefix) bool {
+	return p.Bits() == q.Bits() && p.Len <= q.Len &&
+		p.First().Cmp(q.First()) <= 0 && p.Last().Cmp(q.Last()) >= 0
+}
+
+// Overlaps reports whether p and q share any address.
+func (p Prefix) Overlaps(q Prefix) bool {
+	return p.Bits() == q.Bits() && p.First().Cmp(q.Last()) <= 0 && q.First().Cmp(p.Last()) <= 0
+}
+
+func intToIP(n *big.Int, bits int) net.IP {
+	ip := make(net.IP, bits/8)
+	n.FillBytes(ip)
+	return ip
+}
+
+// RangePrefixes returns the minimal list of prefixes that exactly covers the
+// addresses first to last of a family with the given address length.
+func RangePrefixes(first, last *big.Int, bits int) []Prefix {
+	var prefixes []Prefix
+	one := big.NewInt(1)
+	base := new(big.Int).Set(first)
+	for base.Cmp(last) <= 0 {
+		step := 0
+		for step < bits && base.Bit(step) == 0 {
+			if lastAddress(base, bits-step-1, bits).Cmp(last) > 0 {
+				break
+			}
+			step++
+		}
+		prefixes = append(prefixes, Prefix{IP: intToIP(base, bits), Len: bits - step})
+		base = new(big.Int).Add(base, new(big.Int).Lsh(one, uint(step)))
+	}
+	return prefixes
+}
+
+type addrRange struct {
+	first, last *big.Int
+	bits        int
+}
+
+// mergeRanges sorts the ranges and joins those that overlap or touch.
+func mergeRanges(ranges []addrRange) []addrRange {
+	sort.Slice(ranges, func(i, j int) bool {
+		if ranges[i].bits != ranges[j].bits {
+			return ranges[i].bits < ranges[j].bits
+		}
+		return ranges[i].first.Cmp(ranges[j].first) < 0
+	})
+
+	var merged []addrRange
+	for _, r := range ranges {
+		if len(merged) > 0 {
+			cur := &merged[len(merged)-1]
+			next := new(big.Int).Add(cur.last, big.NewInt(1))
+			if cur.bits == r.bits && r.first.Cmp(next) <= 0 {
+				if r.last.Cmp(cur.last) > 0 {
+					cur.last = r.last
+				}
+				continue
+			}
+		}
+		merged = append(merged, addrRange{first: r.first, last: r.last, bits: r.bits})
+	}
+	return merged
+}
+
+func prefixRanges(prefixes []Prefix) []addrRange {
+	ranges := make([]addrRange, 0, len(prefixes))
+	for _, p := range prefixes {
+		ranges = append(ranges, addrRange{first: p.First(), last: p.Last(), bits: p.Bits()})
+	}
+	return ranges
+}
+
+// Aggregate merges adjacent and overlapping prefixes into the minimal
+// equivalent list. IPv4 prefixes are returned before IPv6 prefixes, each in
+// address order.
+func Aggregate(prefixes []Prefix) []Prefix {
+	var result []Prefix
+	for _, r := range mergeRanges(prefixRanges(prefixes)) {
+		result = append(result, RangePrefixes(r.first, r.last, r.bits)...)
+	}
+	return result
+}
+
+// Exclude returns the minimal list of prefixes covering parent without the
+// addresses of any of the excluded prefixes. Excluded prefixes of the other
+// family are ignored.
+func Exclude(parent Prefix, excluded []Prefix) []Prefix {
+	var result []Prefix
+	one := big.NewInt(1)
+	next := parent.First()
+	last := parent.Last()
+	for _, r := range mergeRanges(prefixRanges(excluded)) {
+		if r.bits != parent.Bits() || r.last.Cmp(next) < 0 || r.first.Cmp(last) > 0 {
+			continue
+		}
+		if r.first.Cmp(next) > 0 {
+			result = append(result, RangePrefixes(next, new(big.Int).Sub(r.first, one), parent.Bits())...)
+		}
+		next = new(big.Int).Add(r.last, one)
+	}
+	if next.Cmp(last) <= 0 {
+		result = append(result, RangePrefixes(next, last, parent.Bits())...)
+	}
+	return result
+}
+
+// Overlap is a pair of prefixes sharing addresses. Outer and Inner are
+// indices into the list passed to FindOverlaps; the Outer prefix contains
+// the Inner one, or is identical to it when Duplicate is set.
+type Overlap struct {
+	Outer     int
+	Inner     int
+	Duplicate bool
+}
+
+// FindOverlaps reports every pair of prefixes in the list that share
+// addresses. Since two overlapping prefixes always nest, each pair is
+// reported as one containing the other, or as duplicates.
+func FindOverlaps(prefixes []Prefix) []Overlap {
+	order := make([]int, len(prefixes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := prefixes[order[i]], prefixes[order[j]]
+		if a.Bits() != b.Bits() {
+			return a.Bits() < b.Bits()
+		}
+		if c := a.First().Cmp(b.First()); c != 0 {
+			return c < 0
+		}
+		return a.Len < b.Len
+	})
+
+	var overlaps []Overlap
+	var open []int
+	for _, i := range order {
+		p := prefixes[i]
+		for len(open) > 0 {
+			top := prefixes[open[len(open)-1]]
+			if top.Bits() == p.Bits() && top.Last().Cmp(p.First()) >= 0 {
+				break
+			}
+			open = open[:len(open)-1]
+		}
+		for _, j := range open {
+			q := prefixes[j]
+			overlaps = append(overlaps, Overlap{Outer: j, Inner: i, Duplicate: q.Len == p.Len})
+		}
+		open = append(open, i)
+	}
+	return overlaps
+}
+
+var (
+	privatePrefixes = []Prefix{
+		mustParsePrefix("10.0.0.0/8"),
+		mustParsePrefix("172.16.0.0/12"),
+		mustParsePrefix("192.168.0.0/16"),
+		mustParsePrefix("fc00::/7"),
+	}
+	multicastPrefixes = []Prefix{
+		mustParsePrefix("224.0.0.0/4"),
+		mustParsePrefix("ff00::/8"),
+	}
+)
+
+func mustParsePrefix(s string) Prefix {
+	p, err := ParsePrefix(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func containedIn(p Prefix, list []Prefix) bool {
+	for _, q := range list {
+		if q.Contains(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Netblock returns the well-known block the prefix lies in, as FindNetblock
+// and FindNetblock6 do.
+func (p Prefix) Netblock() Netblock {
+	if p.Is4() {
+		return FindNetblock(IPToUint32(p.IP), CIDRToMask(p.Len))
+	}
+	return FindNetblock6(p.IP, p.Len)
+}
+
+// IsPrivate reports whether p lies in the RFC 1918 private IPv4 ranges or
+// the RFC 4193 unique local IPv6 range.
+func (p Prefix) IsPrivate() bool {
+	return containedIn(p, privatePrefixes)
+}
+
+// IsMulticast reports whether p lies in the IPv4 or IPv6 multicast range.
+func (p Prefix) IsMulticast() bool {
+	return containedIn(p, multicastPrefixes)
+}
+
+// IsGlobal reports whether p lies entirely in globally reachable unicast
+// space according to the IANA special-purpose registries. IPv4 space not
+// listed there is global; IPv6 space must be in global unicast.
+func (p Prefix) IsGlobal() bool {
+	b := p.Netblock()
+	if b.Partial {
+		return false
+	}
+	if b.Name == "" {
+		return p.Is4()
+	}
+	return b.Attributes != nil && b.Attributes.GloballyReachable
+}
+
+// Cover returns the smallest prefix that contains all the given prefixes.
+// The list must not be empty and must hold a single family.
+func Cover(prefixes []Prefix) Prefix {
+	first, last := prefixes[0].First(), prefixes[0].Last()
+	for _, p := range prefixes[1:] {
+		if f := p.First(); f.Cmp(first) < 0 {
+			first = f
+		}
+		if l := p.Last(); l.Cmp(last) > 0 {
+			last = l
+		}
+	}
+	bits := prefixes[0].Bits()
+	length := bits - new(big.Int).Xor(first, last).BitLen()
+	return Prefix{IP: intToIP(first, bits).Mask(net.CIDRMask(length, bits)), Len: length}
+}
+
+// AddressCount returns the number of distinct addresses in the prefixes.
+func AddressCount(prefixes []Prefix) *big.Int {
+	count := new(big.Int)
+	for _, r := range mergeRanges(prefixRanges(prefixes)) {
+		count.Add(count, new(big.Int).Sub(r.last, r.first))
+		count.Add(count, big.NewInt(1))
+	}
+	return count
+}
@@ -0,0 +1,243 @@
+package ipcalc
+
+import (
+	"strings"
+	"testing"
+)
+
+func mustParsePrefixes(t *testing.T, list string) []Prefix {
+	t.Helper()
+	var prefixes []Prefix
+	for _, s := range strings.Fields(list) {
+		p, err := ParsePrefix(s)
+		if err != nil {
+			t.Fatalf("ParsePrefix(%s): %v", s, err)
+		}
+		prefixes = append(prefixes, p)
+	}
+	return prefixes
+}
+
+func joinPrefixes(prefixes []Prefix) string {
+	var s []string
+	for _, p := range prefixes {
+		s = append(s, p.String())
+	}
+	return strings.Join(s, " ")
+}
+
+func TestParsePrefix(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{"10.1.2.3/8", "10.0.0.0/8", false},
+		{"192.168.1.1", "192.168.1.1/32", false},
+		{"192.168.1.1/255.255.255.0", "192.168.1.0/24", false},
+		{" 172.16.0.0/12 ", "172.16.0.0/12", false},
+		{"2001:db8::1/32", "2001:db8::/32", false},
+		{"2001:db8::1", "2001:db8::1/128", false},
+		{"::ffff:10.1.2.3/120", "::ffff:10.1.2.0/120", false},
+		{"::ffff:0:0/96", "::ffff:0.0.0.0/96", false},
+		{"::ffff:10.1.2.3", "::ffff:10.1.2.3/128", false},
+		{"::ffff:10.0.0.0/255.255.255.0", "", true},
+		{"10.0.0.0/33", "", true},
+		{"2001:db8::/129", "", true},
+		{"host.example", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := ParsePrefix(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePrefix(%s) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && result.String() != tt.expected {
+				t.Errorf("ParsePrefix(%s) = %s, want %s", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPrefixRelations(t *testing.T) {
+	p := mustParsePrefixes(t, "10.0.0.0/16 10.0.1.0/24 10.1.0.0/16 2001:db8::/32 ::a00:0/112")
+	if !p[0].Contains(p[1]) || p[1].Contains(p[0]) {
+		t.Error("10.0.0.0/16 should contain 10.0.1.0/24 and not the reverse")
+	}
+	if !p[0].Overlaps(p[1]) || !p[1].Overlaps(p[0]) {
+		t.Error("10.0.0.0/16 and 10.0.1.0/24 should overlap")
+	}
+	if p[0].Overlaps(p[2]) {
+		t.Error("10.0.0.0/16 and 10.1.0.0/16 should not overlap")
+	}
+	if p[0].Overlaps(p[4]) || p[0].Contains(p[4]) {
+		t.Error("prefixes of different families should never overlap")
+	}
+	if got := p[3].Size().String(); got != "79228162514264337593543950336" {
+		t.Errorf("Size(2001:db8::/32) = %s", got)
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"Adjacent", "10.0.0.0/24 10.0.1.0/24", "10.0.0.0/23"},
+		{"Unaligned adjacent", "10.0.1.0/24 10.0.2.0/24", "10.0.1.0/24 10.0.2.0/24"},
+		{"Contained", "10.0.0.0/8 10.1.2.0/24 10.255.255.255", "10.0.0.0/8"},
+		{"Overlapping and unsorted", "192.168.1.0/24 192.168.0.128/25 192.168.0.0/25 192.168.2.0/23", "192.168.0.0/22"},
+		{"Duplicates", "10.0.0.0/24 10.0.0.0/24", "10.0.0.0/24"},
+		{"Hosts", "10.0.0.0 10.0.0.1 10.0.0.2 10.0.0.3 10.0.0.4", "10.0.0.0/30 10.0.0.4/32"},
+		{"Mixed families", "2001:db8:1::/48 10.0.0.0/25 2001:db8::/48 10.0.0.128/25", "10.0.0.0/24 2001:db8::/47"},
+		{"Everything", "0.0.0.0/1 128.0.0.0/1", "0.0.0.0/0"},
+		{"IPv4-mapped", "::ffff:10.0.0.0/120 ::ffff:10.0.1.0/120 10.0.0.0/24", "10.0.0.0/24 ::ffff:10.0.0.0/119"},
+		{"Empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := joinPrefixes(Aggregate(mustParsePrefixes(t, tt.input)))
+			if result != tt.expected {
+				t.Errorf("Aggregate(%s) = %s, want %s", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExclude(t *testing.T) {
+	tests := []struct {
+		name     string
+		parent   string
+		excluded string
+		expected string
+	}{
+		{"Single /24", "10.0.0.0/22", "10.0.1.0/24", "10.0.0.0/24 10.0.2.0/23"},
+		{"Three /24s", "10.0.0.0/16", "10.0.1.0/24 10.0.5.0/24 10.0.255.0/24",
+			"10.0.0.0/24 10.0.2.0/23 10.0.4.0/24 10.0.6.0/23 10.0.8.0/21 10.0.16.0/20 10.0.32.0/19 10.0.64.0/18 10.0.128.0/18 10.0.192.0/19 10.0.224.0/20 10.0.240.0/21 10.0.248.0/22 10.0.252.0/23 10.0.254.0/24"},
+		{"Nothing", "192.168.0.0/24", "", "192.168.0.0/24"},
+		{"Everything", "192.168.0.0/24", "192.168.0.0/16", ""},
+		{"Outside", "192.168.0.0/24", "10.0.0.0/8", "192.168.0.0/24"},
+		{"Overlapping excludes", "192.168.0.0/23", "192.168.1.128/25 192.168.1.0/24", "192.168.0.0/24"},
+		{"Larger exclude", "192.168.1.0/24", "192.168.0.0/23", ""},
+		{"Host", "192.168.0.0/30", "192.168.0.1", "192.168.0.0/32 192.168.0.2/31"},
+		{"Other family", "192.168.0.0/24", "2001:db8::/32", "192.168.0.0/24"},
+		{"IPv6", "2001:db8::/32", "2001:db8::/34 2001:db8:8000::/33", "2001:db8:4000::/34"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := mustParsePrefixes(t, tt.parent)[0]
+			result := joinPrefixes(Exclude(parent, mustParsePrefixes(t, tt.excluded)))
+			if result != tt.expected {
+				t.Errorf("Exclude(%s, %s) = %s, want %s", tt.parent, tt.excluded, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCover(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		covered  string
+	}{
+		{"Single", "10.0.1.0/24", "10.0.1.0/24", "256"},
+		{"Adjacent", "10.0.0.0/24 10.0.1.0/24", "10.0.0.0/23", "512"},
+		{"Hosts", "10.0.0.1 10.0.0.2 10.0.3.200", "10.0.0.0/22", "3"},
+		{"Nested", "10.0.0.0/16 10.0.1.0/24", "10.0.0.0/16", "65536"},
+		{"Across a boundary", "10.0.0.255 10.0.1.0", "10.0.0.0/23", "2"},
+		{"Everything", "0.0.0.0 255.255.255.255", "0.0.0.0/0", "2"},
+		{"IPv6", "2001:db8::/48 2001:db8:ff::/48", "2001:db8::/40", "2417851639229258349412352"},
+		{"IPv4-mapped", "::ffff:10.0.0.1 ::ffff:10.0.0.9", "::ffff:10.0.0.0/124", "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefixes := mustParsePrefixes(t, tt.input)
+			if got := Cover(prefixes).String(); got != tt.expected {
+				t.Errorf("Cover(%s) = %s, want %s", tt.input, got, tt.expected)
+			}
+			if got := AddressCount(prefixes).String(); got != tt.covered {
+				t.Errorf("AddressCount(%s) = %s, want %s", tt.input, got, tt.covered)
+			}
+		})
+	}
+}
+
+func TestFindOverlaps(t *testing.T) {
+	prefixes := mustParsePrefixes(t, "10.0.0.0/16 192.168.0.0/24 10.0.1.0/24 10.1.0.0/16 10.0.1.128/25 192.168.0.0/24 2001:db8::/32 10.0.0.0/8")
+	type pair struct {
+		outer, inner int
+		dup          bool
+	}
+	expected := []pair{
+		{7, 0, false},
+		{7, 2, false},
+		{0, 2, false},
+		{7, 4, false},
+		{0, 4, false},
+		{2, 4, false},
+		{7, 3, false},
+		{1, 5, true},
+	}
+
+	result := FindOverlaps(prefixes)
+	if len(result) != len(expected) {
+		t.Fatalf("FindOverlaps returned %d overlaps, want %d: %+v", len(result), len(expected), result)
+	}
+	for i, o := range result {
+		if got := (pair{o.Outer, o.Inner, o.Duplicate}); got != expected[i] {
+			t.Errorf("overlap %d = %+v, want %+v", i, got, expected[i])
+		}
+	}
+
+	if result := FindOverlaps(mustParsePrefixes(t, "10.0.0.0/24 10.0.1.0/24 2001:db8::/32 2001:db9::/32")); len(result) != 0 {
+		t.Errorf("FindOverlaps(disjoint) = %+v, want none", result)
+	}
+}
+
+func TestPrefixPredicates(t *testing.T) {
+	tests := []struct {
+		prefix    string
+		private   bool
+		multicast bool
+		global    bool
+	}{
+		{"10.1.2.3", true, false, false},
+		{"172.20.0.0/16", true, false, false},
+		{"192.168.1.0/24", true, false, false},
+		{"192.168.0.0/15", false, false, false},
+		{"8.8.8.8", false, false, true},
+		{"100.64.0.1", false, false, false},
+		{"192.0.2.10", false, false, false},
+		{"192.0.0.9", false, false, true},
+		{"224.0.0.251", false, true, false},
+		{"127.0.0.1", false, false, false},
+		{"fd00::1", true, false, false},
+		{"ff02::1", false, true, false},
+		{"2a00:1450::1", false, false, true},
+		{"2001:db8::1", false, false, false},
+		{"64:ff9b::c000:221", false, false, true},
+		{"4000::1", false, false, false},
+		{"fe80::1", false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			p := mustParsePrefixes(t, tt.prefix)[0]
+			if got := p.IsPrivate(); got != tt.private {
+				t.Errorf("IsPrivate() = %v, want %v", got, tt.private)
+			}
+			if got := p.IsMulticast(); got != tt.multicast {
+				t.Errorf("IsMulticast() = %v, want %v", got, tt.multicast)
+			}
+			if got := p.IsGlobal(); got != tt.global {
+				t.Errorf("IsGlobal() = %v, want %v", got, tt.global)
+			}
+		})
+	}
+}
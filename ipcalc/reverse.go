@@ -0,0 +1,122 @@
+package ipcalc
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// ReverseName returns the in-addr.arpa or ip6.arpa name of the address of
+// host, as used for its PTR record. The name follows the family of host, so
+// an IPv4-mapped IPv6 address gets an ip6.arpa name.
+func ReverseName(host Prefix) string {
+	return arpaName(host.IP, host.Bits())
+}
+
+// arpaName returns the reverse zone name of the first length bits of ip.
+// length must fall on an octet boundary for IPv4 and a nibble boundary for
+// IPv6.
+func arpaName(ip net.IP, length int) string {
+	var labels []string
+	if len(ip) == net.IPv4len {
+		for i := length/8 - 1; i >= 0; i-- {
+			labels = append(labels, fmt.Sprint(ip[i]))
+		}
+		return strings.Join(append(labels, "in-addr.arpa"), ".")
+	}
+	for i := length/4 - 1; i >= 0; i-- {
+		nibble := ip[i/2] >> 4
+		if i%2 == 1 {
+			nibble = ip[i/2] & 0xf
+		}
+		labels = append(labels, fmt.Sprintf("%x", nibble))
+	}
+	return strings.Join(append(labels, "ip6.arpa"), ".")
+}
+
+// ReverseZones returns the reverse zones that together cover p. Zones fall
+// on octet boundaries for IPv4 and nibble boundaries for IPv6, so a prefix
+// between two boundaries needs several zones: a /22 needs four /24 zones.
+// An IPv4 prefix longer than /24 gets the single RFC 2317 classless zone
+// returned by ClasslessDelegation.
+func ReverseZones(p Prefix) []string {
+	if d, ok := ClasslessDelegation(p); ok {
+		return []string{d.Zone}
+	}
+
+	step := 4
+	if p.Is4() {
+		step = 8
+	}
+	length := (p.Len + step - 1) / step * step
+
+	var zones []string
+	count := new(big.Int).Lsh(big.NewInt(1), uint(length-p.Len))
+	for i := new(big.Int); i.Cmp(count) < 0; i.Add(i, big.NewInt(1)) {
+		offset := new(big.Int).Lsh(i, uint(p.Bits()-length))
+		zones = append(zones, arpaName(intToIP(offset.Add(offset, p.First()), p.Bits()), length))
+	}
+	return zones
+}
+
+// Delegation is the RFC 2317 classless delegation of an IPv4 prefix longer
+// than /24: the /24 parent zone holds a CNAME for every address of the
+// prefix pointing into the delegated Zone, named FIRST/LEN under Parent.
+type Delegation struct {
+	Parent string
+	Zone   string
+	CNAMEs []CNAME
+}
+
+// CNAME is an alias from Name to Target. Both are absolute domain names
+// without the trailing dot.
+type CNAME struct {
+	Name   string
+	Target string
+}
+
+// ClasslessDelegation returns the RFC 2317 delegation of p, or false if p
+// is not an IPv4 prefix longer than /24.
+func ClasslessDelegation(p Prefix) (Delegation, bool) {
+	if !p.Is4() || p.Len <= 24 {
+		return Delegation{}, false
+	}
+
+	parent := arpaName(p.IP, 24)
+	d := Delegation{
+		Parent: parent,
+		Zone:   fmt.Sprintf("%d/%d.%s", p.IP[3], p.Len, parent),
+	}
+	first := int(p.IP[3])
+	for host := first; host < first+1<<(32-p.Len); host++ {
+		d.CNAMEs = append(d.CNAMEs, CNAME{
+			Name:   fmt.Sprintf("%d.%s", host, parent),
+			Target: fmt.Sprintf("%d.%s", host, d.Zone),
+		})
+	}
+	return d, true
+}
+
+// ExpandHostname fills in the placeholders of a hostname template for the
+// address of host. {a}, {b}, {c} and {d} are the octets of an IPv4 address,
+// {ip} is the address with its dots or colons replaced by dashes and {hex}
+// is the address as 8 or 32 hex digits. An IPv4-mapped IPv6 address is
+// expanded as IPv6.
+func ExpandHostname(template string, host Prefix) string {
+	pairs := []string{"{hex}", fmt.Sprintf("%x", []byte(host.IP))}
+	addr := host.IP.String()
+	if host.Is4() {
+		pairs = append(pairs,
+			"{a}", fmt.Sprint(host.IP[0]),
+			"{b}", fmt.Sprint(host.IP[1]),
+			"{c}", fmt.Sprint(host.IP[2]),
+			"{d}", fmt.Sprint(host.IP[3]),
+		)
+	} else {
+		addr = netip.AddrFrom16([16]byte(host.IP)).String()
+	}
+	dashed := strings.NewReplacer(".", "-", ":", "-").Replace(addr)
+	return strings.NewReplacer(append(pairs, "{ip}", dashed)...).Replace(template)
+}
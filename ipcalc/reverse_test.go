@@ -0,0 +1,87 @@
+package ipcalc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReverseName(t *testing.T) {
+	tests := []struct {
+		ip       string
+		expected string
+	}{
+		{"192.0.2.65", "65.2.0.192.in-addr.arpa"},
+		{"2001:db8::567:89ab", "b.a.9.8.7.6.5.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa"},
+		{"::ffff:192.0.2.1", "1.0.2.0.0.0.0.c.f.f.f.f.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.ip6.arpa"},
+	}
+
+	for _, tt := range tests {
+		if got := ReverseName(mustParsePrefix(tt.ip)); got != tt.expected {
+			t.Errorf("ReverseName(%s) = %s, want %s", tt.ip, got, tt.expected)
+		}
+	}
+}
+
+func TestReverseZones(t *testing.T) {
+	tests := []struct {
+		prefix   string
+		expected string
+	}{
+		{"0.0.0.0/0", "in-addr.arpa"},
+		{"10.0.0.0/8", "10.in-addr.arpa"},
+		{"192.168.4.0/22", "4.168.192.in-addr.arpa 5.168.192.in-addr.arpa 6.168.192.in-addr.arpa 7.168.192.in-addr.arpa"},
+		{"172.16.0.0/15", "16.172.in-addr.arpa 17.172.in-addr.arpa"},
+		{"192.0.2.64/27", "64/27.2.0.192.in-addr.arpa"},
+		{"2001:db8::/32", "8.b.d.0.1.0.0.2.ip6.arpa"},
+		{"2001:db8:ab00::/39", "a.a.8.b.d.0.1.0.0.2.ip6.arpa b.a.8.b.d.0.1.0.0.2.ip6.arpa"},
+	}
+
+	for _, tt := range tests {
+		p := mustParsePrefix(tt.prefix)
+		if got := strings.Join(ReverseZones(p), " "); got != tt.expected {
+			t.Errorf("ReverseZones(%s) = %s, want %s", tt.prefix, got, tt.expected)
+		}
+	}
+}
+
+func TestClasslessDelegation(t *testing.T) {
+	if _, ok := ClasslessDelegation(mustParsePrefix("192.0.2.0/24")); ok {
+		t.Error("ClasslessDelegation(192.0.2.0/24) reported a delegation")
+	}
+
+	d, ok := ClasslessDelegation(mustParsePrefix("192.0.2.70/27"))
+	if !ok {
+		t.Fatal("ClasslessDelegation(192.0.2.70/27) reported no delegation")
+	}
+	if d.Parent != "2.0.192.in-addr.arpa" || d.Zone != "64/27.2.0.192.in-addr.arpa" {
+		t.Errorf("parent %s, zone %s", d.Parent, d.Zone)
+	}
+	if len(d.CNAMEs) != 32 {
+		t.Fatalf("got %d CNAMEs, want 32", len(d.CNAMEs))
+	}
+	want := CNAME{Name: "95.2.0.192.in-addr.arpa", Target: "95.64/27.2.0.192.in-addr.arpa"}
+	if got := d.CNAMEs[31]; got != want {
+		t.Errorf("last CNAME = %+v, want %+v", got, want)
+	}
+}
+
+func TestExpandHostname(t *testing.T) {
+	tests := []struct {
+		template string
+		ip       string
+		expected string
+	}{
+		{"host-{a}-{b}-{c}-{d}.example.net.", "192.0.2.1", "host-192-0-2-1.example.net."},
+		{"{ip}.{hex}.example.net.", "10.0.0.255", "10-0-0-255.0a0000ff.example.net."},
+		{"v6-{ip}.example.net.", "2001:db8::1", "v6-2001-db8--1.example.net."},
+		{"{hex}.example.net.", "2001:db8::1", "20010db8000000000000000000000001.example.net."},
+		{"{a}.example.net.", "2001:db8::1", "{a}.example.net."},
+		{"{ip}.{hex}.{d}.example.net.", "::ffff:192.0.2.1", "--ffff-192-0-2-1.00000000000000000000ffffc0000201.{d}.example.net."},
+	}
+
+	for _, tt := range tests {
+		if got := ExpandHostname(tt.template, mustParsePrefix(tt.ip)); got != tt.expected {
+			t.Errorf("ExpandHostname(%q, %s) = %s, want %s", tt.template, tt.ip, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package ipcalc
+
+import (
+	"fmt"
+	"math/big"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SubnetCount returns the number of /mask2 subnets in a /mask1 network.
+func SubnetCount(mask1, mask2 int) uint64 {
+	if mask2 < mask1 {
+		return 0
+	}
+	return uint64(1) << (mask2 - mask1)
+}
+
+// Subnet returns the i-th (zero based) /mask2 subnet of network.
+func Subnet(network uint32, mask2 int, i uint64) Network {
+	return NewNetwork(network|uint32(i<<(32-mask2)), mask2)
+}
+
+// SubnetHosts returns the number of hosts in all /mask2 subnets of a /mask1
+// network together, counting /31 and /32 subnets as NewNetwork does.
+func SubnetHosts(mask1, mask2 int) uint64 {
+	hostn := uint64(1)<<(32-mask2) - 2
+	switch mask2 {
+	case 31:
+		hostn = 2
+	case 32:
+		hostn = 1
+	}
+	return hostn * SubnetCount(mask1, mask2)
+}
+
+// Supernet returns the /mask2 network that contains network.
+func Supernet(network uint32, mask2 int) Network {
+	return NewNetwork(network, mask2)
+}
+
+// Allocation is one network handed out by Split.
+type Allocation struct {
+	Requested uint64
+	Network   Network
+}
+
+// SplitResult is the outcome of splitting a network into subnets.
+type SplitResult struct {
+	Allocations []Allocation
+	Needed      uint64
+	Used        Network
+	Unused      []Network
+}
+
+// Split allocates subnets large enough to hold the requested numbers of hosts
+// from the given network, largest first, and reports the space left over. It
+// returns ErrInvalidSize if a size is over 4294967294 hosts or the subnets
+// together do not fit in the network.
+func Split(network uint32, prefix int, sizes []uint64) (SplitResult, error) {
+	var result SplitResult
+	for _, size := range sizes {
+		if size > 1<<32-2 {
+			return SplitResult{}, fmt.Errorf("%w: %d hosts, more than 4294967294", ErrInvalidSize, size)
+		}
+		// Each subnet also takes a network and a broadcast address
+		bitLen := bits.Len64(size + 1)
+		result.Allocations = append(result.Allocations, Allocation{
+			Requested: size,
+			Network:   NewNetwork(0, 32-bitLen),
+		})
+		result.Needed += uint64(1) << bitLen
+	}
+
+	if result.Needed > uint64(1)<<(32-prefix) {
+		return SplitResult{}, fmt.Errorf("%w: %d addresses needed, more than the /%d network holds", ErrInvalidSize, result.Needed, prefix)
+	}
+
+	sort.SliceStable(result.Allocations, func(i, j int) bool {
+		return result.Allocations[i].Network.Prefix < result.Allocations[j].Network.Prefix
+	})
+
+	broadcast := uint64(network | ^CIDRToMask(prefix))
+	currentNet := uint64(network)
+	for i := 0; i < len(result.Allocations) && currentNet <= broadcast; i++ {
+		a := &result.Allocations[i]
+		a.Network = NewNetwork(uint32(currentNet), a.Network.Prefix)
+		currentNet += uint64(1) << (32 - a.Network.Prefix)
+	}
+
+	usedMask := 32
+	if result.Needed > 1 {
+		usedMask = 32 - bits.Len64(result.Needed-1)
+	}
+	result.Used = NewNetwork(network, usedMask)
+
+	if currentNet <= broadcast {
+		result.Unused = Deaggregate(uint32(currentNet), uint32(broadcast))
+	}
+	return result, nil
+}
+
+// Round2PowerOf2 rounds n up to the next power of two.
+func Round2PowerOf2(n int) int {
+	if n <= 0 {
+		return 1
+	}
+	return 1 << bits.Len(uint(n-1))
+}
+
+// Size2BitCountMask returns the longest prefix length whose network holds at
+// least size addresses.
+func Size2BitCountMask(size int) int {
+	if size <= 0 {
+		return 32
+	}
+	return 32 - bits.Len(uint(size-1))
+}
+
+// Deaggregate returns the minimal list of networks that exactly covers the
+// address range start to end.
+func Deaggregate(start, end uint32) []Network {
+	var nets []Network
+	base := uint64(start)
+	for base <= uint64(end) {
+		step := 0
+		for step < 32 && base&(uint64(1)<<step) == 0 {
+			if base|(uint64(1)<<(step+1)-1) > uint64(end) {
+				break
+			}
+			step++
+		}
+		nets = append(nets, NewNetwork(uint32(base), 32-step))
+		base += uint64(1) << step
+	}
+	return nets
+}
+
+// ParseSize accepts a size either as a decimal number or as a power of two
+// written "2^n".
+func ParseSize(arg string) (*big.Int, error) {
+	if exp, ok := strings.CutPrefix(arg, "2^"); ok {
+		n, err := strconv.Atoi(exp)
+		if err != nil || n < 0 || n > 128 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidSize, arg)
+		}
+		return new(big.Int).Lsh(big.NewInt(1), uint(n)), nil
+	}
+	size, ok := new(big.Int).SetString(arg, 10)
+	if !ok || size.Sign() < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSize, arg)
+	}
+	return size, nil
+}
@@ -0,0 +1,378 @@
+package ipcalc
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRound2PowerOf2(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    int
+		expected int
+	}{
+		{"Zero", 0, 1},
+		{"One", 1, 1},
+		{"Two", 2, 2},
+		{"Three", 3, 4},
+		{"Four", 4, 4},
+		{"Five", 5, 8},
+		{"Seven", 7, 8},
+		{"Eight", 8, 8},
+		{"Nine", 9, 16},
+		{"Fifteen", 15, 16},
+		{"Sixteen", 16, 16},
+		{"Seventeen", 17, 32},
+		{"Thirty one", 31, 32},
+		{"Thirty two", 32, 32},
+		{"Negative", -1, 1},
+		{"Large number", 100, 128},
+		{"Very large", 1000, 1024},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Round2PowerOf2(tt.input)
+			if result != tt.expected {
+				t.Errorf("Round2PowerOf2(%d) = %d, want %d", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSize2BitCountMask(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int
+		expected int
+	}{
+		{"Size 1", 1, 32}, // 1 address needs /32
+		{"Size 2", 2, 31},
+		{"Size 3", 3, 30},
+		{"Size 4", 4, 30},
+		{"Size 8", 8, 29},
+		{"Size 16", 16, 28},
+		{"Size 32", 32, 27},
+		{"Size 64", 64, 26},
+		{"Size 128", 128, 25},
+		{"Size 256", 256, 24},
+		{"Size 512", 512, 23},
+		{"Size 1024", 1024, 22},
+		{"Zero", 0, 32},
+		{"Negative", -1, 32},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Size2BitCountMask(tt.size)
+			if result != tt.expected {
+				t.Errorf("Size2BitCountMask(%d) = %d, want %d", tt.size, result, tt.expected)
+			}
+		})
+	}
+}
+
+func deaggregateToSlice(start, end uint32) []string {
+	var results []string
+	for _, n := range Deaggregate(start, end) {
+		results = append(results, Uint32ToIP(n.Network).String()+"/"+strconv.Itoa(n.Prefix))
+	}
+	return results
+}
+
+func TestDeaggregate(t *testing.T) {
+	tests := []struct {
+		name          string
+		start         string
+		end           string
+		expectedCount int // Just verify we get reasonable number of results
+		minCount      int
+		maxCount      int
+	}{
+		{
+			name:          "Single IP",
+			start:         "192.168.0.1",
+			end:           "192.168.0.1",
+			expectedCount: 1,
+			minCount:      1,
+			maxCount:      1,
+		},
+		{
+			name:          "Two IPs",
+			start:         "192.168.0.1",
+			end:           "192.168.0.2",
+			expectedCount: 2,
+			minCount:      2,
+			maxCount:      2,
+		},
+		{
+			name:          "Small range",
+			start:         "192.168.0.1",
+			end:           "192.168.0.10",
+			expectedCount: 5,
+			minCount:      5,
+			maxCount:      5,
+		},
+		{
+			name:          "Aligned /30",
+			start:         "192.168.0.0",
+			end:           "192.168.0.3",
+			expectedCount: 1,
+			minCount:      1,
+			maxCount:      3, // May produce multiple if not perfectly aligned
+		},
+		{
+			name:          "Aligned /28",
+			start:         "192.168.0.0",
+			end:           "192.168.0.15",
+			expectedCount: 1,
+			minCount:      1,
+			maxCount:      5, // May produce multiple if not perfectly aligned
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			startIP := net.ParseIP(tt.start).To4()
+			endIP := net.ParseIP(tt.end).To4()
+			start := IPToUint32(startIP)
+			end := IPToUint32(endIP)
+
+			results := deaggregateToSlice(start, end)
+
+			if len(results) < tt.minCount || len(results) > tt.maxCount {
+				t.Errorf("deaggregate(%s, %s) returned %d results, want between %d and %d", tt.start, tt.end, len(results), tt.minCount, tt.maxCount)
+				t.Errorf("Got: %v", results)
+			}
+
+			// Verify all results are valid CIDR notation
+			for i, result := range results {
+				parts := strings.Split(result, "/")
+				if len(parts) != 2 {
+					t.Errorf("deaggregate(%s, %s)[%d] = %s, invalid format", tt.start, tt.end, i, result)
+				}
+				if net.ParseIP(parts[0]) == nil {
+					t.Errorf("deaggregate(%s, %s)[%d] = %s, invalid IP", tt.start, tt.end, i, result)
+				}
+				cidr, err := strconv.Atoi(parts[1])
+				if err != nil || cidr < 0 || cidr > 32 {
+					t.Errorf("deaggregate(%s, %s)[%d] = %s, invalid CIDR", tt.start, tt.end, i, result)
+				}
+			}
+		})
+	}
+}
+
+func TestDeaggregateRange(t *testing.T) {
+	// Test that deaggregate produces valid ranges (simplified test)
+	testCases := []struct {
+		name  string
+		start string
+		end   string
+	}{
+		{"Small range", "192.168.0.1", "192.168.0.10"},
+		{"Medium range", "192.168.0.1", "192.168.0.100"},
+		{"Aligned range", "192.168.0.0", "192.168.0.255"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			startIP := net.ParseIP(tt.start).To4()
+			endIP := net.ParseIP(tt.end).To4()
+			start := IPToUint32(startIP)
+			end := IPToUint32(endIP)
+
+			results := deaggregateToSlice(start, end)
+
+			// Verify we got some results
+			if len(results) == 0 {
+				t.Errorf("deaggregate(%s, %s) returned no results", tt.start, tt.end)
+				return
+			}
+
+			// Verify all results are valid CIDR notation
+			for i, result := range results {
+				parts := strings.Split(result, "/")
+				if len(parts) != 2 {
+					t.Errorf("deaggregate(%s, %s)[%d] = %s, invalid format", tt.start, tt.end, i, result)
+					continue
+				}
+				if net.ParseIP(parts[0]) == nil {
+					t.Errorf("deaggregate(%s, %s)[%d] = %s, invalid IP", tt.start, tt.end, i, result)
+				}
+				cidr, err := strconv.Atoi(parts[1])
+				if err != nil || cidr < 0 || cidr > 32 {
+					t.Errorf("deaggregate(%s, %s)[%d] = %s, invalid CIDR", tt.start, tt.end, i, result)
+				}
+			}
+
+			// Verify ranges are in order and don't go beyond end
+			for i, result := range results {
+				parts := strings.Split(result, "/")
+				networkIP := net.ParseIP(parts[0]).To4()
+				if networkIP == nil {
+					continue // Already tested above
+				}
+				network := IPToUint32(networkIP)
+				cidr, _ := strconv.Atoi(parts[1])
+				mask := CIDRToMask(cidr)
+				broadcast := network | (^mask)
+
+				if network < start {
+					t.Errorf("Range %s starts before start address %s", result, tt.start)
+				}
+				if broadcast > end {
+					t.Errorf("Range %s extends beyond end address %s", result, tt.end)
+				}
+				if i > 0 {
+					prevParts := strings.Split(results[i-1], "/")
+					prevIP := net.ParseIP(prevParts[0]).To4()
+					if prevIP != nil {
+						prevCIDR, _ := strconv.Atoi(prevParts[1])
+						prevMask := CIDRToMask(prevCIDR)
+						prevBroadcast := IPToUint32(prevIP) | (^prevMask)
+						if network <= prevBroadcast {
+							t.Errorf("Range %s overlaps or is out of order with previous range %s", result, results[i-1])
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDeaggregateExact(t *testing.T) {
+	tests := []struct {
+		start    string
+		end      string
+		expected []string
+	}{
+		{"192.168.0.0", "192.168.0.255", []string{"192.168.0.0/24"}},
+		{"192.168.0.1", "192.168.0.10", []string{"192.168.0.1/32", "192.168.0.2/31", "192.168.0.4/30", "192.168.0.8/31", "192.168.0.10/32"}},
+		{"0.0.0.0", "255.255.255.255", []string{"0.0.0.0/0"}},
+		{"255.255.255.254", "255.255.255.255", []string{"255.255.255.254/31"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.start+"-"+tt.end, func(t *testing.T) {
+			start := IPToUint32(net.ParseIP(tt.start))
+			end := IPToUint32(net.ParseIP(tt.end))
+			result := deaggregateToSlice(start, end)
+			if strings.Join(result, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("Deaggregate(%s, %s) = %v, want %v", tt.start, tt.end, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	network := IPToUint32(net.ParseIP("10.0.0.0"))
+	result, err := Split(network, 24, []uint64{10, 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Allocations) != 2 {
+		t.Fatalf("Split returned %d allocations, want 2", len(result.Allocations))
+	}
+	expected := []struct {
+		requested uint64
+		network   string
+		prefix    int
+	}{
+		{100, "10.0.0.0", 25},
+		{10, "10.0.0.128", 28},
+	}
+	for i, e := range expected {
+		a := result.Allocations[i]
+		if a.Requested != e.requested || Uint32ToIP(a.Network.Network).String() != e.network || a.Network.Prefix != e.prefix {
+			t.Errorf("Allocation %d = %d %s/%d, want %d %s/%d", i, a.Requested, Uint32ToIP(a.Network.Network), a.Network.Prefix, e.requested, e.network, e.prefix)
+		}
+	}
+	if result.Needed != 144 {
+		t.Errorf("Needed = %d, want 144", result.Needed)
+	}
+
+	var unused []string
+	for _, n := range result.Unused {
+		unused = append(unused, Uint32ToIP(n.Network).String()+"/"+strconv.Itoa(n.Prefix))
+	}
+	if got, want := strings.Join(unused, " "), "10.0.0.144/28 10.0.0.160/27 10.0.0.192/26"; got != want {
+		t.Errorf("Unused = %s, want %s", got, want)
+	}
+
+	// At the top of the address space the allocations end at the broadcast
+	// address instead of wrapping around to 0.0.0.0
+	top := IPToUint32(net.ParseIP("255.255.255.0"))
+	result, err = Split(top, 24, []uint64{126, 126})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var allocated []string
+	for _, a := range result.Allocations {
+		allocated = append(allocated, Uint32ToIP(a.Network.Network).String()+"/"+strconv.Itoa(a.Network.Prefix))
+	}
+	if got, want := strings.Join(allocated, " "), "255.255.255.0/25 255.255.255.128/25"; got != want || len(result.Unused) != 0 {
+		t.Errorf("Allocations = %s, Unused = %v, want %s and none", got, result.Unused, want)
+	}
+
+	for _, sizes := range [][]uint64{{500, 500}, {126, 126, 1}, {1 << 32}} {
+		if _, err := Split(top, 24, sizes); !errors.Is(err, ErrInvalidSize) {
+			t.Errorf("Split(255.255.255.0/24, %v) error = %v, want ErrInvalidSize", sizes, err)
+		}
+	}
+}
+
+func TestSubnet(t *testing.T) {
+	network := IPToUint32(net.ParseIP("192.168.0.0"))
+	if got := SubnetCount(24, 26); got != 4 {
+		t.Errorf("SubnetCount(24, 26) = %d, want 4", got)
+	}
+	if got := SubnetHosts(24, 26); got != 248 {
+		t.Errorf("SubnetHosts(24, 26) = %d, want 248", got)
+	}
+	if got := SubnetHosts(0, 32); got != 1<<32 {
+		t.Errorf("SubnetHosts(0, 32) = %d, want %d", got, uint64(1)<<32)
+	}
+	if got := SubnetHosts(30, 31); got != 4 {
+		t.Errorf("SubnetHosts(30, 31) = %d, want 4", got)
+	}
+	if got := SubnetCount(0, 32); got != 1<<32 {
+		t.Errorf("SubnetCount(0, 32) = %d, want %d", got, uint64(1)<<32)
+	}
+	n := Subnet(network, 26, 2)
+	if got := Uint32ToIP(n.Network).String(); got != "192.168.0.128" || n.Prefix != 26 {
+		t.Errorf("Subnet(192.168.0.0, 26, 2) = %s/%d, want 192.168.0.128/26", got, n.Prefix)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{"100", "100", false},
+		{"0", "0", false},
+		{"2^10", "1024", false},
+		{"2^64", "18446744073709551616", false},
+		{"2^129", "", true},
+		{"-5", "", true},
+		{"ten", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := ParseSize(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSize(%s) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && result.String() != tt.expected {
+				t.Errorf("ParseSize(%s) = %s, want %s", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
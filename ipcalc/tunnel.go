@@ -0,0 +1,59 @@
+package ipcalc
+
+import "net"
+
+// Prefixes of the automatic tunneling mechanisms.
+var (
+	SixToFourPrefix = mustParsePrefix("2002::/16")
+	TeredoPrefix    = mustParsePrefix("2001::/32")
+)
+
+// SixToFour returns the 6to4 /48 of the site with IPv4 address v4
+// (RFC 3056).
+func SixToFour(v4 net.IP) Prefix {
+	ip := make(net.IP, net.IPv6len)
+	copy(ip, SixToFourPrefix.IP)
+	copy(ip[2:6], v4.To4())
+	return Prefix{IP: ip, Len: 48}
+}
+
+// Decode6to4 returns the IPv4 address embedded in a 6to4 address, or false
+// if ip is not in 2002::/16.
+func Decode6to4(ip net.IP) (net.IP, bool) {
+	ip16 := ip.To16()
+	if ip16 == nil || !SixToFourPrefix.Contains(Prefix{IP: ip16, Len: 128}) {
+		return nil, false
+	}
+	return net.IPv4(ip16[2], ip16[3], ip16[4], ip16[5]).To4(), true
+}
+
+// Teredo holds the fields of a Teredo address (RFC 4380 section 4). Port
+// and Client are stored obfuscated in the address; these are the plain
+// values.
+type Teredo struct {
+	Server net.IP
+	Flags  uint16
+	Port   uint16
+	Client net.IP
+}
+
+// Cone reports whether the cone flag is set, meaning the client is behind
+// a cone NAT.
+func (t Teredo) Cone() bool {
+	return t.Flags&0x8000 != 0
+}
+
+// DecodeTeredo returns the fields of a Teredo address, or false if ip is
+// not in 2001::/32.
+func DecodeTeredo(ip net.IP) (Teredo, bool) {
+	ip16 := ip.To16()
+	if ip16 == nil || !TeredoPrefix.Contains(Prefix{IP: ip16, Len: 128}) {
+		return Teredo{}, false
+	}
+	return Teredo{
+		Server: net.IPv4(ip16[4], ip16[5], ip16[6], ip16[7]).To4(),
+		Flags:  uint16(ip16[8])<<8 | uint16(ip16[9]),
+		Port:   ^(uint16(ip16[10])<<8 | uint16(ip16[11])),
+		Client: net.IPv4(^ip16[12], ^ip16[13], ^ip16[14], ^ip16[15]).To4(),
+	}, true
+}
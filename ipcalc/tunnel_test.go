@@ -0,0 +1,35 @@
+package ipcalc
+
+import (
+	"net"
+	"testing"
+)
+
+func TestSixToFour(t *testing.T) {
+	if got := SixToFour(net.ParseIP("192.0.2.1")).String(); got != "2002:c000:201::/48" {
+		t.Errorf("SixToFour(192.0.2.1) = %s, want 2002:c000:201::/48", got)
+	}
+
+	v4, ok := Decode6to4(net.ParseIP("2002:c000:201:10::1"))
+	if !ok || v4.String() != "192.0.2.1" {
+		t.Errorf("Decode6to4 = %s, %v, want 192.0.2.1", v4, ok)
+	}
+	if _, ok := Decode6to4(net.ParseIP("2001:db8::1")); ok {
+		t.Error("Decode6to4(2001:db8::1) found an IPv4 address")
+	}
+}
+
+func TestDecodeTeredo(t *testing.T) {
+	// The example of RFC 4380 section 4, with the client at 192.0.2.45:40000
+	td, ok := DecodeTeredo(net.ParseIP("2001:0:4136:e378:8000:63bf:3fff:fdd2"))
+	if !ok {
+		t.Fatal("DecodeTeredo found no Teredo address")
+	}
+	if td.Server.String() != "65.54.227.120" || td.Client.String() != "192.0.2.45" || td.Port != 40000 || !td.Cone() {
+		t.Errorf("DecodeTeredo = %+v", td)
+	}
+
+	if _, ok := DecodeTeredo(net.ParseIP("2001:db8::1")); ok {
+		t.Error("DecodeTeredo(2001:db8::1) found a Teredo address")
+	}
+}
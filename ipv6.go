@@ -2,39 +2,371 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"math/big"
 	"net"
+	"net/netip"
 	"strings"
+
+	"github.com/stenstromen/goipcalc/ipcalc"
 )
 
-func ipcalc6(address net.IP, mask int) {
-	printSummary6(address, mask)
+func ipcalc6(w io.Writer, address net.IP, mask1, mask2 int, split *ipcalc.SplitResult6, ifaces []interfaceAddress) {
+	printSummary6(w, address, mask1)
+	printInterfaces6(w, ifaces)
+
+	if split != nil {
+		split6(w, mask1, *split)
+		return
+	}
+
+	if mask1 < mask2 {
+		printHeading(w, fmt.Sprintf("Subnets after transition from /%d to /%d", mask1, mask2))
+		printBlank(w)
+		subnets6(w, ipcalc.NewNetwork6(address, mask1).Network, mask1, mask2)
+	}
+}
+
+func subnets6(w io.Writer, network net.IP, mask1, mask2 int) {
+	subnetCount := ipcalc.SubnetCount6(mask1, mask2)
+	one := big.NewInt(1)
+
+	// No subnet is written longer than the last one, whose subnet bits are
+	// all ones
+	last := ipcalc.Subnet6(network, mask2, new(big.Int).Sub(subnetCount, one))
+	width := valueWidth6(fmt.Sprintf("%s/%d", formatIP6(last.Network), mask2))
+
+	beginTable(w)
+	printLine6(w, "Netmask", fmt.Sprintf("%d", mask2), width, ipcalc.PrefixLenToN6(mask2), maskColor, mask2, mask2)
+	endTable(w)
+	printBlank(w)
+
+	i, end := subnetWindow(subnetCount)
+
+	for ; i.Cmp(end) < 0; i.Add(i, one) {
+		n := ipcalc.Subnet6(network, mask2, i)
+		printText(w, fmt.Sprintf(" %s.", new(big.Int).Add(i, one)))
+		beginTable(w)
+		printLine6(w, "Prefix", fmt.Sprintf("%s/%d", formatIP6(n.Network), mask2), width, n.Network, binryColor, mask1, mask2)
+		endTable(w)
+	}
+
+	printBlank(w)
+	beginTable(w)
+	printCount(w, "Subnets", subnetCount.String())
+	endTable(w)
+}
+
+// valueWidth6 returns the width of the value column of a table of IPv6
+// lines: the longest value and a space, and with bits no less than the 40
+// columns of the widest address. Nibbles take less room, so their column
+// is only as wide as the values need.
+func valueWidth6(values ...string) int {
+	width := 40
+	if optNibbles {
+		width = 0
+	}
+	for _, v := range values {
+		width = max(width, len(v)+1)
+	}
+	return width
+}
+
+// printLine6 prints a labelled IPv6 value in a column of the given width
+// with the bits of ip, colored like printBinary: the first cidr1 bits in
+// bitColor and the bits added by cidr2 as subnet bits.
+func printLine6(w io.Writer, label, value string, width int, ip net.IP, bitColor string, cidr1, cidr2 int) {
+	if optHTML {
+		fmt.Fprintf(w, "<tr>\n<td>%s:</td>\n<td>%s</td>\n", label, paint(quadsColor, value))
+		if optPrintBits {
+			fmt.Fprintf(w, "<td>%s</td>\n", printBinary6(ip, bitColor, cidr1, cidr2))
+		}
+		fmt.Fprint(w, "</tr>\n")
+		return
+	}
+	fmt.Fprintf(w, "%-9s", label+":")
+	if !optPrintBits {
+		fmt.Fprintln(w, paint(quadsColor, value))
+		return
+	}
+	fmt.Fprint(w, paint(quadsColor, fmt.Sprintf("%-*s", width-1, value)))
+	fmt.Fprintln(w, " "+printBinary6(ip, bitColor, cidr1, cidr2))
+}
+
+// printBinary6 returns the bits of ip in groups of 16, or with --nibbles as
+// hex digits in groups of 4, with a space after the first cidr1 bits. A
+// nibble split by a prefix boundary is shown as bits in brackets.
+func printBinary6(ip net.IP, bitColor string, cidr1, cidr2 int) string {
+	color := func(i int) string {
+		switch {
+		case i <= cidr1:
+			return bitColor
+		case i <= cidr2:
+			return subntColor
+		}
+		return normlColor
+	}
+	boundary := func(line *coloredText, i int) {
+		if i == cidr1 && i > 0 && i < 128 {
+			line.write(normlColor, " ")
+		}
+	}
+
+	var line coloredText
+	if !optNibbles {
+		i := 0
+		for _, c := range ntoB6(ip) {
+			if c == ':' {
+				line.write(normlColor, ":")
+				boundary(&line, i)
+				continue
+			}
+			i++
+			line.write(color(i), string(c))
+			if i%16 != 0 {
+				boundary(&line, i)
+			}
+		}
+		return line.String()
+	}
+
+	ip16 := ip.To16()
+	for n := 0; n < 32; n++ {
+		nibble := ip16[n/2] >> 4
+		if n%2 == 1 {
+			nibble = ip16[n/2] & 0xf
+		}
+		first, last := 4*n+1, 4*n+4
+		if split := cidr1 >= first && cidr1 < last || cidr2 >= first && cidr2 < last; !split {
+			line.write(color(first), fmt.Sprintf("%x", nibble))
+		} else {
+			line.write(normlColor, "[")
+			for i := first; i <= last; i++ {
+				line.write(color(i), fmt.Sprint(nibble>>(last-i)&1))
+				if i < last {
+					boundary(&line, i)
+				}
+			}
+			line.write(normlColor, "]")
+		}
+		if n%4 == 3 && n < 31 {
+			line.write(normlColor, ":")
+		}
+		boundary(&line, last)
+	}
+	return line.String()
 }
 
-func printSummary6(address net.IP, netmask int) {
-	prefix := address.Mask(net.CIDRMask(netmask, 128))
+func printSummary6(w io.Writer, address net.IP, netmask int) {
+	n := ipcalc.NewNetwork6(address, netmask)
 
-	fmt.Printf("%-9s", "Address:")
-	fmt.Printf("%-40s", address.String())
-	fmt.Printf("%-130s", ntoB6(address))
-	fmt.Println()
+	last := ipcalc.BigIntToIP6(ipcalc.Prefix{IP: n.Network.To16(), Len: netmask}.Last())
+	values := []string{
+		formatIP6(address),
+		fmt.Sprintf("%d", netmask),
+		fmt.Sprintf("%s/%d", formatIP6(n.Network), netmask),
+		formatIP6(n.Network),
+		formatIP6(last),
+	}
+	width := valueWidth6(values...)
 
-	fmt.Printf("%-9s", "Netmask:")
-	fmt.Printf("%-40s", fmt.Sprintf("%d", netmask))
-	fmt.Printf("%-130s", ntoB6(prefixLenToN6(netmask)))
-	fmt.Println()
+	beginTable(w)
+	printLine6(w, "Address", values[0], width, address, binryColor, netmask, netmask)
+	printLine6(w, "Netmask", values[1], width, n.Netmask, maskColor, netmask, netmask)
+	printLine6(w, "Prefix", values[2], width, n.Network, binryColor, netmask, netmask)
+	printLine6(w, "First", values[3], width, n.Network, binryColor, netmask, netmask)
+	printLine6(w, "Last", values[4], width, last, binryColor, netmask, netmask)
+	if netmask < 127 {
+		printInfo6(w, "Anycast", fmt.Sprintf("%s (Subnet-Router)", paint(quadsColor, formatIP6(n.Network))))
+	}
+	printInfo6(w, "Size", fmt.Sprintf("%s (2^%d) addresses", paint(quadsColor, ipcalc.SubnetCount6(netmask, 128).String()), 128-netmask))
+	if netmask <= 64 {
+		printInfo6(w, "/64s", fmt.Sprintf("%s (2^%d)", paint(quadsColor, ipcalc.SubnetCount6(netmask, 64).String()), 64-netmask))
+	}
+
+	if mac, ok := ipcalc.MACFromEUI64(address); ok && netmask < 128 {
+		printInfo6(w, "MAC", fmt.Sprintf("%s (EUI-64)", mac))
+	}
+	if v4, note, ok := embeddedIPv4(address); ok {
+		printInfo6(w, "IPv4", fmt.Sprintf("%s (%s)", paint(quadsColor, v4.String()), note))
+	}
+	if v4, ok := ipcalc.Decode6to4(address); ok {
+		printInfo6(w, "6to4", fmt.Sprintf("%s (site %s)", paint(quadsColor, v4.String()), ipcalc.SixToFour(v4)))
+	}
+	if td, ok := ipcalc.DecodeTeredo(address); ok {
+		printInfo6(w, "Teredo", fmt.Sprintf("server %s, client %s port %d, %s",
+			paint(quadsColor, td.Server.String()), paint(quadsColor, td.Client.String()), td.Port, teredoFlags(td)))
+	}
+
+	if n.Netblock.Name != "" {
+		if optHTML {
+			fmt.Fprintf(w, "<tr>\n<td>Type:</td>\n<td colspan=\"2\">%s</td>\n</tr>\n", netblockLink(n.Netblock))
+		} else {
+			fmt.Fprintf(w, "%-9s", "Type:")
+			fmt.Fprintln(w, n.Netblock.Description())
+		}
+	}
+	endTable(w)
+	printBlank(w)
+}
 
-	fmt.Printf("%-9s", "Prefix:")
-	fmt.Printf("%-40s", fmt.Sprintf("%s/%d", prefix.String(), netmask))
-	fmt.Printf("%-130s", ntoB6(prefix))
-	fmt.Println()
-	fmt.Println()
+// formatIP6 formats ip in IPv6 notation even when it is IPv4-mapped, which
+// net.IP.String prints as a plain IPv4 address.
+func formatIP6(ip net.IP) string {
+	return netip.AddrFrom16([16]byte(ip.To16())).String()
+}
+
+// nat64Prefix is the prefix given with --nat64, if any.
+var nat64Prefix *ipcalc.Prefix
+
+func parseNAT64() error {
+	if optNAT64 == "" {
+		return nil
+	}
+	p, err := ipcalc.ParsePrefix(optNAT64)
+	if err == nil && p.Is4() {
+		err = ipcalc.ErrInvalidAddress
+	}
+	if err != nil {
+		return invalid("NAT64 PREFIX", optNAT64, err)
+	}
+	if _, err := ipcalc.EmbedIPv4(p, net.IPv4zero); err != nil {
+		return &inputError{msg: fmt.Sprintf("INVALID NAT64 PREFIX: %s, must be /32, /40, /48, /56, /64 or /96", optNAT64), err: err}
+	}
+	nat64Prefix = &p
+	return nil
+}
+
+// embeddedIPv4 returns the IPv4 address embedded in address under the
+// --nat64 prefix or a well-known prefix, and a note saying which.
+func embeddedIPv4(address net.IP) (net.IP, string, bool) {
+	if nat64Prefix != nil && nat64Prefix.Contains(ipcalc.Prefix{IP: address.To16(), Len: 128}) {
+		v4, _ := ipcalc.ExtractIPv4(*nat64Prefix, address)
+		return v4, "NAT64 " + nat64Prefix.String(), true
+	}
+	v4, p, ok := ipcalc.EmbeddedIPv4(address)
+	if !ok {
+		return nil, "", false
+	}
+	if p.String() == ipcalc.IPv4Mapped.String() {
+		return v4, "IPv4-mapped", true
+	}
+	return v4, "NAT64 " + p.String(), true
+}
+
+// interfaceAddress is an address derived for an interface from a /64
+// prefix, as SLAAC would form it.
+type interfaceAddress struct {
+	Method  string
+	Source  string
+	Address net.IP
+}
+
+// interfaceAddresses returns the addresses that --mac and --secret derive
+// in the /64 of address.
+func interfaceAddresses(address net.IP, mask int) ([]interfaceAddress, error) {
+	if optMAC == "" && optSecret == "" {
+		return nil, nil
+	}
+	if mask != 64 {
+		return nil, &inputError{msg: fmt.Sprintf("INVALID MASK1: %d, interface identifiers need a /64", mask), err: ipcalc.ErrInvalidMask}
+	}
+
+	var ifaces []interfaceAddress
+	if optMAC != "" {
+		mac, err := net.ParseMAC(optMAC)
+		if err != nil {
+			return nil, invalid("MAC", optMAC, ipcalc.ErrInvalidAddress)
+		}
+		ip, err := ipcalc.EUI64(address, mac)
+		if err != nil {
+			return nil, invalid("MAC", optMAC, err)
+		}
+		ifaces = append(ifaces, interfaceAddress{Method: "EUI-64", Source: mac.String(), Address: ip})
+	}
+	if optSecret != "" {
+		if optInterface == "" {
+			return nil, usageError{fmt.Errorf("--secret needs --interface")}
+		}
+		ip := ipcalc.StablePrivacy(address, optInterface, optNetworkID, optDADCounter, []byte(optSecret))
+		ifaces = append(ifaces, interfaceAddress{Method: "RFC 7217", Source: optInterface, Address: ip})
+	}
+	return ifaces, nil
+}
+
+func printInterfaces6(w io.Writer, ifaces []interfaceAddress) {
+	if len(ifaces) == 0 {
+		return
+	}
+	var values []string
+	for _, iface := range ifaces {
+		values = append(values, formatIP6(iface.Address))
+	}
+	width := valueWidth6(values...)
+
+	beginTable(w)
+	for i, iface := range ifaces {
+		label := "EUI-64"
+		if iface.Method != "EUI-64" {
+			label = "Stable"
+		}
+		printLine6(w, label, values[i], width, iface.Address, binryColor, 64, 64)
+	}
+	endTable(w)
+	printBlank(w)
+}
+
+// printInfo6 prints a labelled line of information about an IPv6 address
+// without bits.
+func printInfo6(w io.Writer, label, text string) {
+	if optHTML {
+		fmt.Fprintf(w, "<tr>\n<td>%s:</td>\n<td colspan=\"2\">%s</td>\n</tr>\n", label, text)
+		return
+	}
+	fmt.Fprintf(w, "%-9s", label+":")
+	fmt.Fprintln(w, text)
+}
+
+func teredoFlags(td ipcalc.Teredo) string {
+	if td.Cone() {
+		return fmt.Sprintf("flags 0x%04x (cone NAT)", td.Flags)
+	}
+	return fmt.Sprintf("flags 0x%04x", td.Flags)
+}
+
+func split6(w io.Writer, prefix int, result ipcalc.SplitResult6) {
+	var values []string
+	for _, a := range result.Allocations {
+		values = append(values, fmt.Sprintf("%s/%d", formatIP6(a.Network.Network), a.Network.Prefix))
+	}
+	width := valueWidth6(values...)
+
+	for i, a := range result.Allocations {
+		printHeading(w, fmt.Sprintf("%d. Requested size: %s addresses", i+1, a.Requested))
+		beginTable(w)
+		printLine6(w, "Prefix", values[i], width, a.Network.Network, binryColor, prefix, a.Network.Prefix)
+		endTable(w)
+		printBlank(w)
+	}
+
+	printText(w, fmt.Sprintf("Needed size:  %s addresses.", result.Needed))
+	printText(w, fmt.Sprintf("Used network: %s/%d", formatIP6(result.Used.Address), result.Used.Prefix))
+	printText(w, "Unused:")
+	printDeaggregate6(w, result.Unused)
+}
+
+func printDeaggregate6(w io.Writer, nets []ipcalc.Network6) {
+	var prefixes []string
+	for _, n := range nets {
+		prefixes = append(prefixes, fmt.Sprintf("%s/%d", formatIP6(n.Network), n.Prefix))
+	}
+	printNetworks(w, prefixes)
 }
 
 func ntoB6(ip net.IP) string {
 	var b strings.Builder
 	ip16 := ip.To16()
-	for i := 15; i >= 0; i-- {
+	for i := 0; i < 16; i++ {
 		byteVal := ip16[i]
 		for j := 7; j >= 0; j-- {
 			if (byteVal & (1 << j)) != 0 {
@@ -43,24 +375,9 @@ func ntoB6(ip net.IP) string {
 				b.WriteString("0")
 			}
 		}
-		if i > 0 && i%2 == 0 {
+		if i < 15 && i%2 == 1 {
 			b.WriteString(":")
 		}
 	}
 	return b.String()
 }
-
-func prefixLenToN6(prefixLen int) net.IP {
-	n := big.NewInt(0)
-	for i := 127; i > 127-prefixLen; i-- {
-		n.Or(n, big.NewInt(0).Lsh(big.NewInt(1), uint(i)))
-	}
-	return bigIntToIP6(n)
-}
-
-func bigIntToIP6(n *big.Int) net.IP {
-	ip := make(net.IP, 16)
-	bytes := n.Bytes()
-	copy(ip[16-len(bytes):], bytes)
-	return ip
-}
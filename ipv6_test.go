@@ -1,7 +1,11 @@
 package main
 
 import (
+	"bytes"
+	"fmt"
 	"net"
+	"net/netip"
+	"strings"
 	"testing"
 )
 
@@ -41,112 +45,100 @@ func TestNtoB6(t *testing.T) {
 	}
 }
 
-func TestPrefixLenToN6(t *testing.T) {
+func TestNtoB6Order(t *testing.T) {
+	got := ntoB6(net.ParseIP("2001:db8::1"))
+	want := "0010000000000001:0000110110111000:" + strings.Repeat("0000000000000000:", 5) + "0000000000000001"
+	if got != want {
+		t.Errorf("ntoB6(2001:db8::1) = %s, want %s", got, want)
+	}
+}
+
+func TestPrintBinary6(t *testing.T) {
+	defer func(color, html, nibbles bool) { optColor, optHTML, optNibbles = color, html, nibbles }(optColor, optHTML, optNibbles)
+	optColor, optHTML = false, false
+
 	tests := []struct {
-		name     string
-		prefix   int
-		validate func(net.IP) bool
+		ip           string
+		cidr1, cidr2 int
+		nibbles      bool
+		expected     string
 	}{
-		{"Prefix /0", 0, func(ip net.IP) bool {
-			return ip.Equal(net.ParseIP("::"))
-		}},
-		{"Prefix /64", 64, func(ip net.IP) bool {
-			// Should have first 64 bits set
-			ip16 := ip.To16()
-			for i := 0; i < 8; i++ {
-				if ip16[i] != 0xFF {
-					return false
-				}
-			}
-			for i := 8; i < 16; i++ {
-				if ip16[i] != 0x00 {
-					return false
-				}
-			}
-			return true
-		}},
-		{"Prefix /128", 128, func(ip net.IP) bool {
-			// All bits should be set
-			ip16 := ip.To16()
-			for i := 0; i < 16; i++ {
-				if ip16[i] != 0xFF {
-					return false
-				}
-			}
-			return true
-		}},
+		{"2001:db8::1", 32, 32, false, "0010000000000001:0000110110111000: 0000000000000000:0000000000000000:0000000000000000:0000000000000000:0000000000000000:0000000000000001"},
+		{"ffff::", 4, 4, false, "1111 111111111111:0000000000000000:0000000000000000:0000000000000000:0000000000000000:0000000000000000:0000000000000000:0000000000000000"},
+		{"2001:db8::1", 64, 64, true, "2001:0db8:0000:0000: 0000:0000:0000:0001"},
+		{"2001:db8:ab00::", 39, 39, true, "2001:0db8:a[101 1]00:0000:0000:0000:0000:0000"},
+		{"2001:db8:ab00::", 32, 34, true, "2001:0db8: [1010]b00:0000:0000:0000:0000:0000"},
+		{"::", 0, 0, true, "0000:0000:0000:0000:0000:0000:0000:0000"},
+		{"::1", 128, 128, true, "0000:0000:0000:0000:0000:0000:0000:0001"},
 	}
 
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := prefixLenToN6(tt.prefix)
-			if result == nil {
-				t.Errorf("prefixLenToN6(%d) returned nil", tt.prefix)
-				return
-			}
-			if !tt.validate(result) {
-				t.Errorf("prefixLenToN6(%d) = %s, validation failed", tt.prefix, result.String())
-			}
-		})
+		optNibbles = tt.nibbles
+		if got := printBinary6(net.ParseIP(tt.ip), binryColor, tt.cidr1, tt.cidr2); got != tt.expected {
+			t.Errorf("printBinary6(%s, /%d, /%d, nibbles %v) =\n%s\nwant\n%s", tt.ip, tt.cidr1, tt.cidr2, tt.nibbles, got, tt.expected)
+		}
 	}
-}
 
-func TestBigIntToIP6(t *testing.T) {
-	tests := []struct {
-		name     string
-		value    string // Hex representation
-		expected string
-	}{
-		{"Zero", "0", "::"},
-		{"One", "1", "::1"},
-		{"Max", "ffffffffffffffffffffffffffffffff", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"},
+	optColor, optNibbles = true, false
+	got := printBinary6(net.ParseIP("ffff::"), binryColor, 2, 4)
+	if want := colorYellow + "11" + colorReset + " " + colorGreen + "11" + colorReset; !strings.HasPrefix(got, want) {
+		t.Errorf("colored printBinary6 starts %q, want %q", got[:len(want)], want)
 	}
+}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// This is a basic test - the actual implementation uses big.Int
-			// We'll just verify the function exists and doesn't crash
-			ip := net.ParseIP(tt.expected)
-			if ip == nil {
-				t.Fatalf("Failed to parse expected IP: %s", tt.expected)
+func TestPrintLine6Width(t *testing.T) {
+	defer func(color, html, bits, nibbles bool) {
+		optColor, optHTML, optPrintBits, optNibbles = color, html, bits, nibbles
+	}(optColor, optHTML, optPrintBits, optNibbles)
+	optColor, optHTML, optPrintBits = false, false, true
+
+	// The lines of a table share their prefix lengths and so the width of
+	// their bits; aligned, they are all as long as the longest value needs
+	for _, nibbles := range []bool{false, true} {
+		optNibbles = nibbles
+		for _, args := range []string{"2001:db8:ab00::1 39", "2001:db8::1 64", "::1 128", "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff 120"} {
+			fields := strings.Fields(args)
+			var mask int
+			fmt.Sscan(fields[1], &mask)
+			var buf bytes.Buffer
+			printSummary6(&buf, net.ParseIP(fields[0]), mask)
+
+			lines := strings.Split(buf.String(), "\n")[:5]
+			values := make([]string, len(lines))
+			for i, line := range lines {
+				values[i] = strings.Fields(line)[1]
 			}
-			// The function is internal, so we test it indirectly through prefixLenToN6
-			// which uses it
-			result := prefixLenToN6(128)
-			if result == nil {
-				t.Error("prefixLenToN6(128) returned nil")
+			want := 9 + valueWidth6(values...) + len(printBinary6(net.ParseIP(fields[0]), binryColor, mask, mask))
+			for _, line := range lines {
+				if len(line) != want {
+					t.Errorf("%s, nibbles %v: line %q is %d columns, want %d", args, nibbles, line, len(line), want)
+				}
 			}
-		})
+		}
 	}
 }
 
-func TestIPv6AddressMasking(t *testing.T) {
+func TestParseNAT64(t *testing.T) {
+	defer func() { optNAT64, nat64Prefix = "", nil }()
+
 	tests := []struct {
-		name   string
-		ip     string
-		mask   int
-		result string
+		prefix string
+		want   int
 	}{
-		{"/64 mask", "2001:0db8:85a3:0000:0000:8a2e:0370:7334", 64, "2001:db8:85a3::"},
-		{"/48 mask", "2001:0db8:85a3:0000:0000:8a2e:0370:7334", 48, "2001:db8:85a3::"},
-		{"/128 mask", "2001:0db8:85a3:0000:0000:8a2e:0370:7334", 128, "2001:db8:85a3::8a2e:370:7334"},
+		{"64:ff9b:1::/48", exitOK},
+		{"::ffff:0:0/96", exitOK},
+		{"192.0.2.0/24", exitInvalidAddress},
+		{"2001:db8::/36", exitInvalidMask},
 	}
 
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			ip := net.ParseIP(tt.ip)
-			if ip == nil {
-				t.Fatalf("Failed to parse IP: %s", tt.ip)
-			}
-			prefix := ip.Mask(net.CIDRMask(tt.mask, 128))
-			expected := net.ParseIP(tt.result)
-			if expected == nil {
-				t.Fatalf("Failed to parse expected IP: %s", tt.result)
-			}
-			if !prefix.Equal(expected) {
-				t.Errorf("Masking %s with /%d = %s, want %s", tt.ip, tt.mask, prefix.String(), tt.result)
-			}
-		})
+		optNAT64, nat64Prefix = tt.prefix, nil
+		err := parseNAT64()
+		if got := exitCode(err); got != tt.want {
+			t.Errorf("parseNAT64(%s) = %v, exit code %d, want %d", tt.prefix, err, got, tt.want)
+		}
+		if err == nil && nat64Prefix.String() != netip.MustParsePrefix(tt.prefix).String() {
+			t.Errorf("parseNAT64(%s) = %s", tt.prefix, nat64Prefix)
+		}
 	}
 }
-
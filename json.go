@@ -0,0 +1,758 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+
+	"github.com/stenstromen/goipcalc/ipcalc"
+)
+
+// bigNumber is a *big.Int written to JSON as a decimal string. IPv6 counts
+// and sizes run past 2^53, beyond which readers that parse JSON numbers as
+// doubles, like jq and JavaScript, round them.
+type bigNumber big.Int
+
+func (n *bigNumber) String() string {
+	return (*big.Int)(n).String()
+}
+
+func (n *bigNumber) MarshalJSON() ([]byte, error) {
+	return json.Marshal(n.String())
+}
+
+type networkReport struct {
+	Network     string             `json:"network"`
+	Prefix      int                `json:"prefix"`
+	Netmask     string             `json:"netmask"`
+	Wildcard    string             `json:"wildcard"`
+	Broadcast   string             `json:"broadcast,omitempty"`
+	HostMin     string             `json:"host_min"`
+	HostMax     string             `json:"host_max"`
+	Hosts       uint64             `json:"hosts"`
+	Class       string             `json:"class"`
+	Netblock    string             `json:"netblock,omitempty"`
+	Attributes  *ipcalc.Attributes `json:"attributes,omitempty"`
+	Description string             `json:"description"`
+}
+
+type subnetsReport struct {
+	Prefix    int
+	Netmask   string
+	Wildcard  string
+	Count     uint64
+	Hosts     uint64
+	Offset    uint64
+	Networks  stream[networkReport]
+	Truncated bool
+}
+
+func (r *subnetsReport) writeJSON(o *jsonObject) {
+	o.member("prefix", r.Prefix)
+	o.member("netmask", r.Netmask)
+	o.member("wildcard", r.Wildcard)
+	o.member("count", r.Count)
+	o.member("hosts", r.Hosts)
+	if r.Offset != 0 {
+		o.member("offset", r.Offset)
+	}
+	streamMember(o, "networks", r.Networks)
+	if r.Truncated {
+		o.member("truncated", r.Truncated)
+	}
+}
+
+type allocationReport struct {
+	Requested uint64        `json:"requested"`
+	Network   networkReport `json:"network"`
+}
+
+type splitReport struct {
+	Allocations []allocationReport `json:"allocations"`
+	Needed      uint64             `json:"needed"`
+	Used        string             `json:"used"`
+	Unused      []networkReport    `json:"unused"`
+}
+
+type ipv4Report struct {
+	Address  string
+	Netmask  string
+	Prefix   int
+	Wildcard string
+	Network  networkReport
+	Subnets  *subnetsReport
+	Supernet *networkReport
+	Split    *splitReport
+}
+
+func (r ipv4Report) writeJSON(o *jsonObject) {
+	o.member("address", r.Address)
+	o.member("netmask", r.Netmask)
+	o.member("prefix", r.Prefix)
+	o.member("wildcard", r.Wildcard)
+	o.member("network", r.Network)
+	if r.Subnets != nil {
+		o.object("subnets", r.Subnets)
+	}
+	if r.Supernet != nil {
+		o.member("supernet", r.Supernet)
+	}
+	if r.Split != nil {
+		o.member("split", r.Split)
+	}
+}
+
+type ipv6Report struct {
+	Address    string
+	Prefix     int
+	Netmask    string
+	Network    network6Report
+	First      string
+	Last       string
+	Anycast    string
+	Addresses  *bigNumber
+	AddrsLog2  int
+	Subnets64  *bigNumber
+	MAC        string
+	IPv4       *embeddedIPv4Report
+	SixToFour  *sixToFourReport
+	Teredo     *teredoReport
+	Interfaces []interfaceReport
+	Subnets    *subnets6Report
+	Split      *split6Report
+}
+
+func (r ipv6Report) writeJSON(o *jsonObject) {
+	o.member("address", r.Address)
+	o.member("prefix", r.Prefix)
+	o.member("netmask", r.Netmask)
+	o.member("network", r.Network)
+	o.member("first", r.First)
+	o.member("last", r.Last)
+	if r.Anycast != "" {
+		o.member("subnet_router_anycast", r.Anycast)
+	}
+	o.member("addresses", r.Addresses)
+	o.member("addresses_log2", r.AddrsLog2)
+	if r.Subnets64 != nil {
+		o.member("networks_64", r.Subnets64)
+	}
+	if r.MAC != "" {
+		o.member("mac", r.MAC)
+	}
+	if r.IPv4 != nil {
+		o.member("ipv4", r.IPv4)
+	}
+	if r.SixToFour != nil {
+		o.member("6to4", r.SixToFour)
+	}
+	if r.Teredo != nil {
+		o.member("teredo", r.Teredo)
+	}
+	if len(r.Interfaces) > 0 {
+		o.member("interfaces", r.Interfaces)
+	}
+	if r.Subnets != nil {
+		o.object("subnets", r.Subnets)
+	}
+	if r.Split != nil {
+		o.member("split", r.Split)
+	}
+}
+
+type embeddedIPv4Report struct {
+	Address string `json:"address"`
+	Note    string `json:"note"`
+}
+
+type sixToFourReport struct {
+	IPv4   string `json:"ipv4"`
+	Prefix string `json:"prefix"`
+}
+
+type teredoReport struct {
+	Server string `json:"server"`
+	Client string `json:"client"`
+	Port   uint16 `json:"port"`
+	Flags  uint16 `json:"flags"`
+	Cone   bool   `json:"cone"`
+}
+
+type interfaceReport struct {
+	Method  string `json:"method"`
+	Source  string `json:"source"`
+	Address string `json:"address"`
+}
+
+type allocation6Report struct {
+	Requested *bigNumber     `json:"requested"`
+	Network   network6Report `json:"network"`
+}
+
+type split6Report struct {
+	Allocations []allocation6Report `json:"allocations"`
+	Needed      *bigNumber          `json:"needed"`
+	Used        string              `json:"used"`
+	Unused      []network6Report    `json:"unused"`
+}
+
+type network6Report struct {
+	Network    string             `json:"network"`
+	Prefix     int                `json:"prefix"`
+	Netmask    string             `json:"netmask"`
+	Netblock   string             `json:"netblock,omitempty"`
+	Attributes *ipcalc.Attributes `json:"attributes,omitempty"`
+}
+
+type subnets6Report struct {
+	Prefix    int
+	Netmask   string
+	Count     *bigNumber
+	Offset    *bigNumber
+	Networks  stream[network6Report]
+	Truncated bool
+}
+
+func (r *subnets6Report) writeJSON(o *jsonObject) {
+	o.member("prefix", r.Prefix)
+	o.member("netmask", r.Netmask)
+	o.member("count", r.Count)
+	if r.Offset != nil {
+		o.member("offset", r.Offset)
+	}
+	streamMember(o, "networks", r.Networks)
+	if r.Truncated {
+		o.member("truncated", r.Truncated)
+	}
+}
+
+type rangeReport struct {
+	Start    string          `json:"start"`
+	End      string          `json:"end"`
+	Networks []networkReport `json:"networks"`
+}
+
+type range6Report struct {
+	Start    string           `json:"start"`
+	End      string           `json:"end"`
+	Networks []network6Report `json:"networks"`
+}
+
+type aggregateReport struct {
+	Input    int      `json:"input"`
+	Networks []string `json:"networks"`
+}
+
+type excludeReport struct {
+	Network  string   `json:"network"`
+	Excluded []string `json:"excluded"`
+	Networks []string `json:"networks"`
+}
+
+type coverReport struct {
+	Input        []string   `json:"input"`
+	Network      string     `json:"network"`
+	Size         *bigNumber `json:"size"`
+	Covered      *bigNumber `json:"covered"`
+	Extra        *bigNumber `json:"extra"`
+	ExtraPercent float64    `json:"extra_percent"`
+}
+
+type cnameReport struct {
+	Name   string `json:"name"`
+	Target string `json:"target"`
+}
+
+type delegationReport struct {
+	Parent      string        `json:"parent"`
+	Zone        string        `json:"zone"`
+	NameServers []string      `json:"name_servers,omitempty"`
+	CNAMEs      []cnameReport `json:"cnames"`
+}
+
+type reverseNetworkReport struct {
+	Network    string            `json:"network"`
+	Zones      []string          `json:"zones"`
+	Delegation *delegationReport `json:"delegation,omitempty"`
+}
+
+type reverseReport struct {
+	Networks []reverseNetworkReport `json:"networks"`
+}
+
+type ptrRecordReport struct {
+	Name     string `json:"name"`
+	Hostname string `json:"hostname"`
+}
+
+type ptrReport struct {
+	Hosts   string            `json:"hosts"`
+	Records []ptrRecordReport `json:"records"`
+}
+
+type labeledPrefixReport struct {
+	Network string `json:"network"`
+	Label   string `json:"label,omitempty"`
+}
+
+type overlapEntryReport struct {
+	Relation string              `json:"relation"`
+	Outer    labeledPrefixReport `json:"outer"`
+	Inner    labeledPrefixReport `json:"inner"`
+}
+
+type overlapReport struct {
+	Networks []labeledPrefixReport `json:"networks"`
+	Overlaps []overlapEntryReport  `json:"overlaps"`
+}
+
+type checkResultReport struct {
+	Address string `json:"address"`
+	Result  bool   `json:"result"`
+}
+
+type checkReport struct {
+	Result    bool                `json:"result"`
+	Addresses []checkResultReport `json:"addresses"`
+}
+
+type countReport struct {
+	Count *bigNumber `json:"count"`
+}
+
+type classReport struct {
+	Address   string `json:"address"`
+	ClassBits *int   `json:"class_bits"`
+}
+
+func newNetworkReport(n ipcalc.Network) networkReport {
+	r := networkReport{
+		Network:     ipcalc.Uint32ToIP(n.Network).String(),
+		Prefix:      n.Prefix,
+		Netmask:     ipcalc.Uint32ToIP(n.Netmask).String(),
+		Wildcard:    ipcalc.Uint32ToIP(n.Wildcard).String(),
+		HostMin:     ipcalc.Uint32ToIP(n.HostMin).String(),
+		HostMax:     ipcalc.Uint32ToIP(n.HostMax).String(),
+		Hosts:       n.Hosts,
+		Class:       n.Class,
+		Netblock:    n.Netblock.String(),
+		Attributes:  netblockAttributes(n.Netblock),
+		Description: getDescription(n),
+	}
+	if n.Prefix < 31 {
+		r.Broadcast = ipcalc.Uint32ToIP(n.Broadcast).String()
+	}
+	return r
+}
+
+// netblockAttributes returns the attributes of a block only when they apply
+// to the whole network.
+func netblockAttributes(b ipcalc.Netblock) *ipcalc.Attributes {
+	if b.Partial {
+		return nil
+	}
+	return b.Attributes
+}
+
+func newNetworkReports(nets []ipcalc.Network) []networkReport {
+	reports := make([]networkReport, 0, len(nets))
+	for _, n := range nets {
+		reports = append(reports, newNetworkReport(n))
+	}
+	return reports
+}
+
+func newIPv4Report(address uint32, mask1, mask2 int, split *ipcalc.SplitResult) ipv4Report {
+	n := ipcalc.NewNetwork(address, mask1)
+	r := ipv4Report{
+		Address:  ipcalc.Uint32ToIP(address).String(),
+		Netmask:  ipcalc.Uint32ToIP(n.Netmask).String(),
+		Prefix:   mask1,
+		Wildcard: ipcalc.Uint32ToIP(n.Wildcard).String(),
+		Network:  newNetworkReport(n),
+	}
+
+	switch {
+	case split != nil:
+		r.Split = newSplitReport(*split)
+	case mask1 < mask2:
+		r.Subnets = newSubnetsReport(n.Network, mask1, mask2)
+	case mask1 > mask2:
+		supernet := newNetworkReport(ipcalc.Supernet(n.Network, mask2))
+		r.Supernet = &supernet
+	}
+	return r
+}
+
+func newSubnetsReport(network uint32, mask1, mask2 int) *subnetsReport {
+	mask2Uint := ipcalc.CIDRToMask(mask2)
+	r := &subnetsReport{
+		Prefix:   mask2,
+		Netmask:  ipcalc.Uint32ToIP(mask2Uint).String(),
+		Wildcard: ipcalc.Uint32ToIP(^mask2Uint).String(),
+		Count:    ipcalc.SubnetCount(mask1, mask2),
+		Hosts:    ipcalc.SubnetHosts(mask1, mask2),
+	}
+	first, end := subnetWindow4(r.Count)
+	r.Networks = func(yield func(networkReport) bool) {
+		for i := first; i < end; i++ {
+			if !yield(newNetworkReport(ipcalc.Subnet(network, mask2, i))) {
+				return
+			}
+		}
+	}
+	r.Offset = first
+	r.Truncated = end < r.Count
+	return r
+}
+
+func newSplitReport(result ipcalc.SplitResult) *splitReport {
+	r := &splitReport{
+		Allocations: []allocationReport{},
+		Needed:      result.Needed,
+		Used:        fmt.Sprintf("%s/%d", ipcalc.Uint32ToIP(result.Used.Address), result.Used.Prefix),
+		Unused:      newNetworkReports(result.Unused),
+	}
+	for _, a := range result.Allocations {
+		r.Allocations = append(r.Allocations, allocationReport{
+			Requested: a.Requested,
+			Network:   newNetworkReport(a.Network),
+		})
+	}
+	return r
+}
+
+func newNetwork6Report(n ipcalc.Network6) network6Report {
+	return network6Report{
+		Network:    formatIP6(n.Network),
+		Prefix:     n.Prefix,
+		Netmask:    n.Netmask.String(),
+		Netblock:   n.Netblock.String(),
+		Attributes: netblockAttributes(n.Netblock),
+	}
+}
+
+func newNetwork6Reports(nets []ipcalc.Network6) []network6Report {
+	reports := make([]network6Report, 0, len(nets))
+	for _, n := range nets {
+		reports = append(reports, newNetwork6Report(n))
+	}
+	return reports
+}
+
+func newIPv6Report(address net.IP, mask1, mask2 int, split *ipcalc.SplitResult6, ifaces []interfaceAddress) ipv6Report {
+	n := ipcalc.NewNetwork6(address, mask1)
+	r := ipv6Report{
+		Address:   formatIP6(n.Address),
+		Prefix:    n.Prefix,
+		Netmask:   n.Netmask.String(),
+		Network:   newNetwork6Report(n),
+		First:     formatIP6(n.Network),
+		Last:      formatIP6(ipcalc.BigIntToIP6(ipcalc.Prefix{IP: n.Network.To16(), Len: n.Prefix}.Last())),
+		Addresses: (*bigNumber)(ipcalc.SubnetCount6(n.Prefix, 128)),
+		AddrsLog2: 128 - n.Prefix,
+	}
+	if n.Prefix < 127 {
+		r.Anycast = formatIP6(n.Network)
+	}
+	if n.Prefix <= 64 {
+		r.Subnets64 = (*bigNumber)(ipcalc.SubnetCount6(n.Prefix, 64))
+	}
+	if mac, ok := ipcalc.MACFromEUI64(address); ok && mask1 < 128 {
+		r.MAC = mac.String()
+	}
+	if v4, note, ok := embeddedIPv4(address); ok {
+		r.IPv4 = &embeddedIPv4Report{Address: v4.String(), Note: note}
+	}
+	if v4, ok := ipcalc.Decode6to4(address); ok {
+		r.SixToFour = &sixToFourReport{IPv4: v4.String(), Prefix: ipcalc.SixToFour(v4).String()}
+	}
+	if td, ok := ipcalc.DecodeTeredo(address); ok {
+		r.Teredo = &teredoReport{
+			Server: td.Server.String(),
+			Client: td.Client.String(),
+			Port:   td.Port,
+			Flags:  td.Flags,
+			Cone:   td.Cone(),
+		}
+	}
+	for _, iface := range ifaces {
+		r.Interfaces = append(r.Interfaces, interfaceReport{Method: iface.Method, Source: iface.Source, Address: formatIP6(iface.Address)})
+	}
+	switch {
+	case split != nil:
+		r.Split = newSplit6Report(*split)
+	case mask1 < mask2:
+		r.Subnets = newSubnets6Report(n.Network, mask1, mask2)
+	}
+	return r
+}
+
+func newSubnets6Report(network net.IP, mask1, mask2 int) *subnets6Report {
+	count := ipcalc.SubnetCount6(mask1, mask2)
+	r := &subnets6Report{
+		Prefix:  mask2,
+		Netmask: ipcalc.PrefixLenToN6(mask2).String(),
+		Count:   (*bigNumber)(count),
+	}
+	first, end := subnetWindow(count)
+	if first.Sign() > 0 {
+		r.Offset = (*bigNumber)(new(big.Int).Set(first))
+	}
+	r.Networks = func(yield func(network6Report) bool) {
+		one := big.NewInt(1)
+		for i := new(big.Int).Set(first); i.Cmp(end) < 0; i.Add(i, one) {
+			if !yield(newNetwork6Report(ipcalc.Subnet6(network, mask2, i))) {
+				return
+			}
+		}
+	}
+	r.Truncated = end.Cmp(count) < 0
+	return r
+}
+
+func newRangeReport(start, end uint32) rangeReport {
+	return rangeReport{
+		Start:    ipcalc.Uint32ToIP(start).String(),
+		End:      ipcalc.Uint32ToIP(end).String(),
+		Networks: newNetworkReports(ipcalc.Deaggregate(start, end)),
+	}
+}
+
+func newSplit6Report(result ipcalc.SplitResult6) *split6Report {
+	r := &split6Report{
+		Allocations: []allocation6Report{},
+		Needed:      (*bigNumber)(result.Needed),
+		Used:        fmt.Sprintf("%s/%d", formatIP6(result.Used.Address), result.Used.Prefix),
+		Unused:      newNetwork6Reports(result.Unused),
+	}
+	for _, a := range result.Allocations {
+		r.Allocations = append(r.Allocations, allocation6Report{
+			Requested: (*bigNumber)(a.Requested),
+			Network:   newNetwork6Report(a.Network),
+		})
+	}
+	return r
+}
+
+func newRange6Report(start, end net.IP) range6Report {
+	return range6Report{
+		Start:    formatIP6(start),
+		End:      formatIP6(end),
+		Networks: newNetwork6Reports(ipcalc.Deaggregate6(start, end)),
+	}
+}
+
+func newAggregateReport(input int, prefixes []ipcalc.Prefix) aggregateReport {
+	return aggregateReport{Input: input, Networks: prefixStrings(prefixes)}
+}
+
+func newExcludeReport(parent ipcalc.Prefix, excluded, remaining []ipcalc.Prefix) excludeReport {
+	return excludeReport{
+		Network:  parent.String(),
+		Excluded: prefixStrings(excluded),
+		Networks: prefixStrings(remaining),
+	}
+}
+
+func newCoverReport(input []string, cover ipcalc.Prefix, covered *big.Int) coverReport {
+	extra := new(big.Int).Sub(cover.Size(), covered)
+	return coverReport{
+		Input:        input,
+		Network:      cover.String(),
+		Size:         (*bigNumber)(cover.Size()),
+		Covered:      (*bigNumber)(covered),
+		Extra:        (*bigNumber)(extra),
+		ExtraPercent: extraPercent(extra, cover.Size()),
+	}
+}
+
+func newReverseReport(prefixes []ipcalc.Prefix, nameServers []string) reverseReport {
+	r := reverseReport{Networks: []reverseNetworkReport{}}
+	for _, p := range prefixes {
+		n := reverseNetworkReport{Network: p.String(), Zones: ipcalc.ReverseZones(p)}
+		if d, ok := ipcalc.ClasslessDelegation(p); ok {
+			n.Delegation = &delegationReport{Parent: d.Parent, Zone: d.Zone}
+			for _, ns := range nameServers {
+				n.Delegation.NameServers = append(n.Delegation.NameServers, fqdn(ns))
+			}
+			for _, c := range d.CNAMEs {
+				n.Delegation.CNAMEs = append(n.Delegation.CNAMEs, cnameReport{Name: c.Name, Target: c.Target})
+			}
+		}
+		r.Networks = append(r.Networks, n)
+	}
+	return r
+}
+
+func newPTRReport(name string, first, last ipcalc.Prefix, template string) ptrReport {
+	r := ptrReport{Hosts: name, Records: []ptrRecordReport{}}
+	hostAddresses(first, last, func(host ipcalc.Prefix) {
+		r.Records = append(r.Records, ptrRecordReport{
+			Name:     ipcalc.ReverseName(host),
+			Hostname: ipcalc.ExpandHostname(template, host),
+		})
+	})
+	return r
+}
+
+func newOverlapReport(prefixes []ipcalc.Prefix, labels []string, overlaps []ipcalc.Overlap) overlapReport {
+	entry := func(i int) labeledPrefixReport {
+		return labeledPrefixReport{Network: prefixes[i].String(), Label: labels[i]}
+	}
+	r := overlapReport{
+		Networks: []labeledPrefixReport{},
+		Overlaps: []overlapEntryReport{},
+	}
+	for i := range prefixes {
+		r.Networks = append(r.Networks, entry(i))
+	}
+	for _, o := range overlaps {
+		relation := "contains"
+		if o.Duplicate {
+			relation = "duplicate"
+		}
+		r.Overlaps = append(r.Overlaps, overlapEntryReport{
+			Relation: relation,
+			Outer:    entry(o.Outer),
+			Inner:    entry(o.Inner),
+		})
+	}
+	return r
+}
+
+func prefixStrings(prefixes []ipcalc.Prefix) []string {
+	s := make([]string, 0, len(prefixes))
+	for _, p := range prefixes {
+		s = append(s, p.String())
+	}
+	return s
+}
+
+// stream is a list produced one element at a time as it is written, so
+// that subnet listings of any length are never held in memory. Templates
+// range over it like a slice; reports holding one write themselves to JSON
+// with writeJSON.
+type stream[T any] func(yield func(T) bool)
+
+// jsonStreamer is a report holding a stream. printJSON writes it member by
+// member rather than marshaling it whole.
+type jsonStreamer interface {
+	writeJSON(o *jsonObject)
+}
+
+// jsonWriter encodes JSON values to w, remembering the first error.
+type jsonWriter struct {
+	w   io.Writer
+	buf bytes.Buffer
+	enc *json.Encoder
+	err error
+}
+
+func newJSONWriter(w io.Writer) *jsonWriter {
+	jw := &jsonWriter{w: w}
+	jw.enc = json.NewEncoder(&jw.buf)
+	return jw
+}
+
+func (jw *jsonWriter) write(s string) {
+	if jw.err == nil {
+		_, jw.err = io.WriteString(jw.w, s)
+	}
+}
+
+// value writes v indented as if it started at indent, without a newline.
+func (jw *jsonWriter) value(v any, indent string) {
+	if jw.err != nil {
+		return
+	}
+	jw.buf.Reset()
+	jw.enc.SetIndent(indent, "  ")
+	if jw.err = jw.enc.Encode(v); jw.err == nil {
+		_, jw.err = jw.w.Write(bytes.TrimSuffix(jw.buf.Bytes(), []byte("\n")))
+	}
+}
+
+// jsonObject writes an indented JSON object one member at a time.
+type jsonObject struct {
+	jw      *jsonWriter
+	indent  string
+	members int
+}
+
+// key starts the next member.
+func (o *jsonObject) key(name string) {
+	if o.members == 0 {
+		o.jw.write("{")
+	} else {
+		o.jw.write(",")
+	}
+	o.members++
+	o.jw.write("\n" + o.indent + "  ")
+	o.jw.value(name, "")
+	o.jw.write(": ")
+}
+
+func (o *jsonObject) member(name string, v any) {
+	o.key(name)
+	o.jw.value(v, o.indent+"  ")
+}
+
+func (o *jsonObject) object(name string, v jsonStreamer) {
+	o.key(name)
+	nested := &jsonObject{jw: o.jw, indent: o.indent + "  "}
+	v.writeJSON(nested)
+	nested.end()
+}
+
+func (o *jsonObject) end() {
+	if o.members == 0 {
+		o.jw.write("{}")
+	} else {
+		o.jw.write("\n" + o.indent + "}")
+	}
+}
+
+// streamMember writes s as an array member, one element at a time.
+func streamMember[T any](o *jsonObject, name string, s stream[T]) {
+	o.key(name)
+	n := 0
+	if s != nil {
+		s(func(v T) bool {
+			if n == 0 {
+				o.jw.write("[")
+			} else {
+				o.jw.write(",")
+			}
+			n++
+			o.jw.write("\n" + o.indent + "    ")
+			o.jw.value(v, o.indent+"    ")
+			return o.jw.err == nil
+		})
+	}
+	if n == 0 {
+		o.jw.write("[]")
+	} else {
+		o.jw.write("\n" + o.indent + "  ]")
+	}
+}
+
+// printJSON writes v as indented JSON. Reports holding a stream are written
+// member by member, the stream one element at a time.
+func printJSON(w io.Writer, v any) error {
+	s, ok := v.(jsonStreamer)
+	if !ok {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	jw := newJSONWriter(w)
+	o := &jsonObject{jw: jw}
+	s.writeJSON(o)
+	o.end()
+	jw.write("\n")
+	return jw.err
+}
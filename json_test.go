@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"iter"
+	"math/big"
+	"net"
+	"slices"
+	"testing"
+
+	"github.com/stenstromen/goipcalc/ipcalc"
+)
+
+func TestNewIPv4Report(t *testing.T) {
+	address := ipcalc.IPToUint32(net.ParseIP("192.168.0.1"))
+
+	r := newIPv4Report(address, 24, 26, nil)
+	if r.Network.Network != "192.168.0.0" || r.Network.Broadcast != "192.168.0.255" || r.Network.Hosts != 254 {
+		t.Errorf("Network = %+v", r.Network)
+	}
+	if r.Subnets == nil || r.Subnets.Count != 4 {
+		t.Fatalf("Subnets = %+v, want 4 networks", r.Subnets)
+	}
+	subnets := slices.Collect(iter.Seq[networkReport](r.Subnets.Networks))
+	if len(subnets) != 4 || subnets[3].Network != "192.168.0.192" {
+		t.Errorf("Subnets.Networks = %+v, want 4 ending in 192.168.0.192", subnets)
+	}
+	if r.Supernet != nil || r.Split != nil {
+		t.Errorf("unexpected supernet or split in subnet report")
+	}
+
+	r = newIPv4Report(address, 24, 16, nil)
+	if r.Supernet == nil || r.Supernet.Network != "192.168.0.0" || r.Supernet.Prefix != 16 {
+		t.Errorf("Supernet = %+v, want 192.168.0.0/16", r.Supernet)
+	}
+
+	split, err := ipcalc.Split(ipcalc.NewNetwork(address, 24).Network, 24, []uint64{60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r = newIPv4Report(address, 24, 24, &split)
+	if r.Split == nil || len(r.Split.Allocations) != 1 || r.Split.Allocations[0].Network.Prefix != 26 {
+		t.Errorf("Split = %+v, want one /26 allocation", r.Split)
+	}
+}
+
+func TestNewIPv6Report(t *testing.T) {
+	r := newIPv6Report(net.ParseIP("2001:db8::1"), 48, 48, nil, nil)
+	if r.First != "2001:db8::" || r.Last != "2001:db8:0:ffff:ffff:ffff:ffff:ffff" || r.Anycast != "2001:db8::" {
+		t.Errorf("First, Last, Anycast = %s, %s, %s", r.First, r.Last, r.Anycast)
+	}
+	if r.AddrsLog2 != 80 || (*big.Int)(r.Addresses).Cmp(new(big.Int).Lsh(big.NewInt(1), 80)) != 0 {
+		t.Errorf("Addresses = %s (2^%d), want 2^80", r.Addresses, r.AddrsLog2)
+	}
+	if r.Subnets64 == nil || (*big.Int)(r.Subnets64).Int64() != 65536 {
+		t.Errorf("Subnets64 = %v, want 65536", r.Subnets64)
+	}
+
+	r = newIPv6Report(net.ParseIP("2001:db8::1"), 127, 127, nil, nil)
+	if r.Anycast != "" || r.Subnets64 != nil || r.Last != "2001:db8::1" {
+		t.Errorf("/127 report = %+v", r)
+	}
+
+	// The network is an object like the IPv4 one
+	if r.Network.Network != "2001:db8::" || r.Network.Prefix != 127 || r.Network.Netblock != "Documentation" {
+		t.Errorf("Network = %+v", r.Network)
+	}
+
+	// IPv4-mapped addresses keep their IPv6 notation throughout
+	r = newIPv6Report(net.ParseIP("::ffff:10.0.0.1"), 120, 121, nil, nil)
+	if r.Network.Network != "::ffff:10.0.0.0" || r.Network.Prefix != 120 || r.Network.Netblock != "IPv4-mapped" {
+		t.Errorf("Network = %+v", r.Network)
+	}
+	subnets := slices.Collect(iter.Seq[network6Report](r.Subnets.Networks))
+	if len(subnets) != 2 || subnets[1].Network != "::ffff:10.0.0.128" {
+		t.Errorf("Subnets.Networks = %+v", subnets)
+	}
+	if r := newRange6Report(net.ParseIP("::ffff:10.0.0.1"), net.ParseIP("::ffff:10.0.0.2")); r.Start != "::ffff:10.0.0.1" || r.Networks[0].Network != "::ffff:10.0.0.1" {
+		t.Errorf("range report = %+v", r)
+	}
+}
+
+func TestNetworkReportJSON(t *testing.T) {
+	n := ipcalc.NewNetwork(ipcalc.IPToUint32(net.ParseIP("10.0.0.1")), 31)
+	data, err := json.Marshal(newNetworkReport(n))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := decoded["broadcast"]; ok {
+		t.Errorf("/31 report has a broadcast address: %s", data)
+	}
+	if decoded["description"] != "Class A, Private Internet (src/dst, forwardable, not global), PtP Link RFC 3021" {
+		t.Errorf("description = %v", decoded["description"])
+	}
+}
+
+func TestBigNumberJSON(t *testing.T) {
+	// 2^64+1 would come back as 2^64 from a reader parsing doubles
+	count := new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), 64), big.NewInt(1))
+	data, err := json.Marshal(countReport{Count: (*bigNumber)(count)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), `{"count":"18446744073709551617"}`; got != want {
+		t.Errorf("count report = %s, want %s", got, want)
+	}
+}
+
+func TestStreamedReportJSON(t *testing.T) {
+	defer func() { subnetOffset, subnetLimit = new(big.Int), nil }()
+	subnetOffset, subnetLimit = big.NewInt(1), big.NewInt(2)
+
+	var buf bytes.Buffer
+	if err := printJSON(&buf, newIPv6Report(net.ParseIP("2001:db8::"), 48, 52, nil, nil)); err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Subnets struct {
+			Count     string `json:"count"`
+			Offset    string `json:"offset"`
+			Networks  []network6Report
+			Truncated bool `json:"truncated"`
+		} `json:"subnets"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("%v in %s", err, buf.Bytes())
+	}
+	s := decoded.Subnets
+	if s.Count != "16" || s.Offset != "1" || !s.Truncated || len(s.Networks) != 2 || s.Networks[0].Network != "2001:db8:0:1000::" {
+		t.Errorf("subnets = %+v", s)
+	}
+
+	// Written member by member, the report reads as if marshaled whole
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, buf.Bytes(), "", "  "); err != nil || indented.String() != buf.String() {
+		t.Errorf("report is not indented JSON: %s", buf.Bytes())
+	}
+}
@@ -1,29 +1,42 @@
 package main
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"math/big"
 	"net"
 	"os"
-	"strconv"
+	"slices"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/stenstromen/goipcalc/ipcalc"
 )
 
 const version = "v0.0.0"
 
 var (
 	optHTML           = false
+	optJSON           = false
 	optColor          = false
 	optPrintBits      = true
 	optPrintOnlyClass = false
-	optSplit          = false
 	optDeaggregate    = false
-	optSplitSizes     []int
+	optSplitSizes     []string
+	optBatch          = false
+	optBatchFile      string
+	optMAC            string
+	optSecret         string
+	optInterface      string
+	optNetworkID      string
+	optDADCounter     uint32
+	optNAT64          string
+	opt6to4           bool
+	optNibbles        bool
 )
 
-var classBits = []int{0, 8, 16, 24, 4, 5, 5}
-
 var rootCmd = &cobra.Command{
 	Use:   "ipcalc [options] <ADDRESS>[[/]<NETMASK>] [NETMASK]",
 	Short: "IP Calculator - Calculate network information from IP addresses and netmasks",
@@ -37,9 +50,22 @@ easy-to-understand binary values.`,
   ipcalc 192.168.0.1 255.255.128.0 255.255.192.0
   ipcalc 192.168.0.1 0.0.63.255
   ipcalc <ADDRESS1> - <ADDRESS2>  deaggregate address range
-  ipcalc <ADDRESS>/<NETMASK> -s a b c  split network to subnets`,
-	Run:     runIPCalc,
-	Version: version,
+  ipcalc <ADDRESS>/<NETMASK> -s a b c  split network to subnets
+  ipcalc 2001:db8::/48 /56            IPv6 subnets
+  ipcalc 10.0.0.0/8 /30 --offset 1000 --limit 10  page through subnets
+  ipcalc 2001:db8::/48 -s 2^10,2^64   split IPv6 prefix by address count
+  ipcalc 2001:db8::/64 --mac 00:11:22:33:44:55  SLAAC EUI-64 address
+  ipcalc 192.0.2.33 --nat64 64:ff9b::/96         NAT64 address of 192.0.2.33
+  ipcalc 192.0.2.1 --6to4                         6to4 prefix of 192.0.2.1
+  ipcalc --json 192.168.0.1/24 /26    subnets as JSON
+  ipcalc --batch < hosts.txt          one calculation per input line
+  ipcalc --format '{{.Network.HostMin}}-{{.Network.HostMax}}' 10.0.0.0/24`,
+	Args:              cobra.ArbitraryArgs,
+	RunE:              runIPCalc,
+	Version:           version,
+	PersistentPreRunE: preRunFormat,
+	SilenceErrors:     true,
+	SilenceUsage:      true,
 }
 
 var (
@@ -53,19 +79,46 @@ func init() {
 	rootCmd.Flags().BoolVarP(&flagNoColor, "nocolor", "n", false, "Don't display ANSI color codes")
 	rootCmd.Flags().BoolVarP(&flagNoBinary, "nobinary", "b", false, "Suppress the bitwise output")
 	rootCmd.Flags().BoolVarP(&optPrintOnlyClass, "class", "c", false, "Just print bit-count-mask of given address")
-	rootCmd.Flags().BoolVar(&optHTML, "html", false, "Display results as HTML (not finished in this version)")
+	rootCmd.Flags().BoolVar(&optNibbles, "nibbles", false, "Display IPv6 bits as hex nibbles")
+	rootCmd.Flags().BoolVar(&optHTML, "html", false, "Display results as an HTML5 document")
+	rootCmd.PersistentFlags().BoolVar(&optJSON, "json", false, "Display results as JSON")
+	rootCmd.PersistentFlags().StringVar(&optFormat, "format", "", "Render results with a Go text/template, given inline or as a file name")
+	rootCmd.Flags().BoolVar(&optCSV, "csv", false, "List the resulting networks as CSV")
+	rootCmd.Flags().BoolVar(&optTSV, "tsv", false, "List the resulting networks as tab-separated values")
+	rootCmd.Flags().StringVar(&optOffset, "offset", "", "Skip this many subnets of the listing")
+	rootCmd.Flags().StringVar(&optLimit, "limit", "", "List at most this many subnets")
+	rootCmd.Flags().BoolVar(&optCountOnly, "count-only", false, "Only print the number of subnets")
 	rootCmd.Flags().BoolVarP(&optDeaggregate, "range", "r", false, "Deaggregate address range")
-	rootCmd.Flags().IntSliceVarP(&optSplitSizes, "split", "s", []int{}, "Split into networks of specified sizes")
+	rootCmd.Flags().BoolVar(&optBatch, "batch", false, "Read one calculation per line from stdin")
+	rootCmd.Flags().StringVarP(&optBatchFile, "file", "f", "", "Read one calculation per line from file (- for stdin)")
+	rootCmd.Flags().StringVar(&optMAC, "mac", "", "Derive the modified EUI-64 address of this MAC in an IPv6 /64")
+	rootCmd.Flags().StringVar(&optSecret, "secret", "", "Derive the RFC 7217 stable-privacy address with this secret key")
+	rootCmd.Flags().StringVar(&optInterface, "interface", "", "Interface name for --secret")
+	rootCmd.Flags().StringVar(&optNetworkID, "network-id", "", "Network ID (e.g. an SSID) for --secret")
+	rootCmd.Flags().Uint32Var(&optDADCounter, "dad-counter", 0, "DAD counter for --secret")
+	rootCmd.Flags().StringVar(&optNAT64, "nat64", "", "Embed IPv4 addresses in, or extract them from, this RFC 6052 prefix")
+	rootCmd.Flags().BoolVar(&opt6to4, "6to4", false, "Calculate the 6to4 /48 of an IPv4 address")
+	rootCmd.Flags().StringSliceVarP(&optSplitSizes, "split", "s", []string{}, "Split into networks of specified sizes (hosts for IPv4, addresses for IPv6, n or 2^n)")
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return usageError{err}
+	})
 }
 
 func main() {
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	var status exitStatus
+	var inputErr *inputError
+	switch {
+	case err == nil, errors.As(err, &status):
+	case errors.As(err, &inputErr):
+		fmt.Fprintln(os.Stderr, err)
+	default:
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
 	}
+	os.Exit(exitCode(err))
 }
 
-func runIPCalc(cmd *cobra.Command, args []string) {
+func runIPCalc(cmd *cobra.Command, args []string) error {
 	// Handle color flags
 	if flagNoColor {
 		optColor = false
@@ -83,69 +136,90 @@ func runIPCalc(cmd *cobra.Command, args []string) {
 		optColor = false
 	}
 
+	// JSON, template and CSV output are plain data, never decorated, and HTML
+	// is colored through CSS
+	if structuredOutput() || tableOutput() {
+		optHTML = false
+	}
+	if structuredOutput() || tableOutput() || optHTML {
+		optColor = false
+	}
+
 	// Handle --nobinary flag (inverted logic)
 	if flagNoBinary {
 		optPrintBits = false
 	}
 
-	// Handle --split flag
-	if len(optSplitSizes) > 0 {
-		optSplit = true
+	// -f implies --batch
+	if optBatchFile != "" {
+		optBatch = true
 	}
 
-	if optHTML {
-		printHTMLHeader()
+	if len(args) == 0 && !optBatch {
+		cmd.Help()
+		return exitStatus(exitUsage)
+	}
+	if optBatch && len(args) > 0 {
+		return usageError{errors.New("--batch reads its calculations from input and takes no arguments")}
 	}
 
-	if len(args) == 0 {
-		cmd.Help()
-		os.Exit(1)
+	if err := parsePaging(); err != nil {
+		return err
+	}
+	if err := parseNAT64(); err != nil {
+		return err
+	}
+	if opt6to4 && nat64Prefix != nil {
+		return usageError{errors.New("--6to4 and --nat64 cannot be combined")}
 	}
 
-	// Detect ADDRESS1 - ADDRESS2 format (standalone "-" argument)
-	if len(args) == 3 && args[1] == "-" {
-		optDeaggregate = true
+	// The document is closed however the calculation ends
+	if optHTML {
+		printHTMLHeader(os.Stdout)
+		defer printHTMLFooter(os.Stdout)
 	}
 
-	// Handle --range flag: Check for ADDRESS1 - ADDRESS2 format BEFORE parsing
-	if optDeaggregate {
-		// Check for ADDRESS1 - ADDRESS2 format
-		if len(args) == 3 && args[1] == "-" {
-			args = []string{args[0], args[2]}
+	if optBatch {
+		if tableOutput() {
+			if err := printHeader(os.Stdout); err != nil {
+				return err
+			}
 		}
-		if len(args) < 2 {
-			fmt.Fprintf(os.Stderr, "INVALID ADDRESS2\n")
-			os.Exit(1)
+		ok, err := batchFile(os.Stdout, os.Stderr, optBatchFile)
+		if err != nil {
+			return err
 		}
-		// Parse addresses for deaggregate
-		addressStr := args[0]
-		address2Str := args[1]
-
-		var address, address2 net.IP
-		if ip := net.ParseIP(addressStr); ip != nil {
-			if ip.To4() == nil {
-				fmt.Fprintf(os.Stderr, "Deaggregate only supports IPv4\n")
-				os.Exit(1)
-			}
-			address = ip.To4()
-		} else {
-			fmt.Fprintf(os.Stderr, "INVALID ADDRESS: %s\n", addressStr)
-			os.Exit(1)
+		if !ok {
+			return exitStatus(exitError)
 		}
+		return nil
+	}
 
-		if ip := net.ParseIP(address2Str); ip != nil {
-			if ip.To4() == nil {
-				fmt.Fprintf(os.Stderr, "Deaggregate only supports IPv4\n")
-				os.Exit(1)
-			}
-			address2 = ip.To4()
-		} else {
-			fmt.Fprintf(os.Stderr, "INVALID ADDRESS2: %s\n", address2Str)
-			os.Exit(1)
-		}
+	// Long subnet listings are written as they are calculated
+	out := bufio.NewWriter(os.Stdout)
+	err := calculate(out, args)
+	if flushErr := out.Flush(); err == nil {
+		err = flushErr
+	}
+	return err
+}
 
-		deaggregate(ipToUint32(address), ipToUint32(address2))
-		os.Exit(0)
+// calculate runs the calculation for one command line worth of arguments,
+// an address with its netmasks or an address range, and writes the result
+// to w.
+func calculate(w io.Writer, args []string) error {
+	// Detect ADDRESS1 - ADDRESS2 format (standalone "-" argument)
+	deaggregate := optDeaggregate
+	if len(args) == 3 && args[1] == "-" {
+		deaggregate = true
+		args = []string{args[0], args[2]}
+	}
+
+	if deaggregate {
+		if len(args) < 2 {
+			return usageError{errors.New("missing ADDRESS2")}
+		}
+		return calculateRange(w, args[0], args[1])
 	}
 
 	// Parse address/netmask combinations
@@ -153,7 +227,9 @@ func runIPCalc(cmd *cobra.Command, args []string) {
 	for _, arg := range args {
 		if strings.Contains(arg, "/") {
 			parts := strings.SplitN(arg, "/", 2)
-			parsedArgs = append(parsedArgs, parts[0])
+			if parts[0] != "" {
+				parsedArgs = append(parsedArgs, parts[0])
+			}
 			if parts[1] != "" {
 				parsedArgs = append(parsedArgs, parts[1])
 			}
@@ -161,112 +237,254 @@ func runIPCalc(cmd *cobra.Command, args []string) {
 			parsedArgs = append(parsedArgs, arg)
 		}
 	}
+	if len(parsedArgs) == 0 {
+		return invalid("ADDRESS", strings.Join(args, " "), ipcalc.ErrInvalidAddress)
+	}
 
 	addressStr := parsedArgs[0]
 	var address net.IP
 	var isIPv6 bool
 
-	// Try parsing as IPv6 first
+	// Addresses written in IPv6 notation, IPv4-mapped ones included, are
+	// IPv6
 	if ip := net.ParseIP(addressStr); ip != nil {
-		if ip.To4() == nil {
+		if ip.To4() == nil || strings.Contains(addressStr, ":") {
 			isIPv6 = true
 			address = ip
 		} else {
 			address = ip.To4()
 		}
 	} else {
-		fmt.Fprintf(os.Stderr, "INVALID ADDRESS: %s\n", addressStr)
-		os.Exit(1)
+		return invalid("ADDRESS", addressStr, ipcalc.ErrInvalidAddress)
+	}
+
+	// An IPv4 address with --nat64 becomes the IPv6 address it translates to
+	if nat64Prefix != nil && !isIPv6 {
+		if len(parsedArgs) > 1 {
+			return usageError{errors.New("--nat64 embeds an IPv4 address without netmask")}
+		}
+		ip, err := ipcalc.EmbedIPv4(*nat64Prefix, address)
+		if err != nil {
+			return err
+		}
+		address, isIPv6 = ip, true
+		parsedArgs = append(parsedArgs, fmt.Sprint(nat64Prefix.Len))
+	}
+
+	// and with --6to4 the 6to4 prefix of its site
+	if opt6to4 && !isIPv6 {
+		if len(parsedArgs) > 1 {
+			return usageError{errors.New("--6to4 takes an IPv4 address without netmask")}
+		}
+		p := ipcalc.SixToFour(address)
+		address, isIPv6 = p.IP, true
+		parsedArgs = append(parsedArgs, fmt.Sprint(p.Len))
+	}
+
+	var splitSizes []*big.Int
+	for _, arg := range optSplitSizes {
+		size, err := ipcalc.ParseSize(arg)
+		if err != nil {
+			return invalid("SIZE", arg, err)
+		}
+		splitSizes = append(splitSizes, size)
 	}
 
 	if optPrintOnlyClass {
+		if structuredOutput() {
+			r := classReport{Address: address.String()}
+			if !isIPv6 {
+				bits := ipcalc.ClassBits(address)
+				r.ClassBits = &bits
+			}
+			return printReport(w, r)
+		}
 		if isIPv6 {
-			fmt.Println("N/A")
+			printText(w, "N/A")
 		} else {
-			fmt.Println(getClassBits(address))
+			printText(w, fmt.Sprint(ipcalc.ClassBits(address)))
 		}
-		os.Exit(0)
+		return nil
 	}
 
 	if isIPv6 {
 		mask1 := 64
-		if len(args) > 1 {
-			m, err := strconv.Atoi(args[1])
-			if err != nil || m < 0 || m > 128 {
-				fmt.Fprintf(os.Stderr, "INVALID MASK1\n")
-				os.Exit(1)
+		if len(parsedArgs) > 1 {
+			m, err := ipcalc.ParsePrefixLen6(parsedArgs[1])
+			if err != nil {
+				return invalid("MASK1", parsedArgs[1], err)
 			}
 			mask1 = m
 		}
-		ipcalc6(address, mask1)
-		os.Exit(0)
+		mask2 := mask1
+		if len(parsedArgs) > 2 {
+			m, err := ipcalc.ParsePrefixLen6(parsedArgs[2])
+			if err != nil {
+				return invalid("MASK2", parsedArgs[2], err)
+			}
+			mask2 = m
+		}
+		ifaces, err := interfaceAddresses(address, mask1)
+		if err != nil {
+			return err
+		}
+		var split *ipcalc.SplitResult6
+		if len(splitSizes) > 0 {
+			result, err := ipcalc.Split6(ipcalc.NewNetwork6(address, mask1).Network, mask1, splitSizes)
+			if err != nil {
+				return invalid("SIZE", strings.Join(optSplitSizes, ","), err)
+			}
+			split = &result
+		}
+		if optCountOnly {
+			return printSubnetCount(w, ipcalc.SubnetCount6(mask1, mask2))
+		}
+		if structuredOutput() {
+			return printReport(w, newIPv6Report(address, mask1, mask2, split, ifaces))
+		}
+		if tableOutput() {
+			return printRows(w, ipv6Rows(address, mask1, mask2, split))
+		}
+		ipcalc6(w, address, mask1, mask2, split, ifaces)
+		return nil
+	}
+
+	if optMAC != "" || optSecret != "" {
+		return usageError{errors.New("--mac and --secret need an IPv6 /64 prefix")}
 	}
 
 	// IPv4 processing
 	mask1 := 24
 	if len(parsedArgs) > 1 {
-		m, err := parseNetmask(parsedArgs[1])
+		m, err := ipcalc.ParseNetmask(parsedArgs[1])
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "INVALID MASK1: %s\n", parsedArgs[1])
-			os.Exit(1)
+			return invalid("MASK1", parsedArgs[1], err)
 		}
 		mask1 = m
 	}
 
 	mask2 := mask1
 	if len(parsedArgs) > 2 {
-		m, err := parseNetmask(parsedArgs[2])
+		m, err := ipcalc.ParseNetmask(parsedArgs[2])
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "INVALID MASK2: %s\n", parsedArgs[2])
-			os.Exit(1)
+			return invalid("MASK2", parsedArgs[2], err)
 		}
 		mask2 = m
 	}
 
-	if optHTML {
-		fmt.Print("<table border=\"0\" cellspacing=\"0\" cellpadding=\"0\">\n")
+	// Each size takes a power of two with the network and broadcast
+	// address, and together they must fit in the network
+	var split *ipcalc.SplitResult
+	if len(splitSizes) > 0 {
+		var hostSizes []uint64
+		for _, size := range splitSizes {
+			if !size.IsUint64() || size.Uint64() > 1<<32-2 {
+				return invalid("SIZE", size.String(), fmt.Errorf("%w: more than 4294967294 hosts", ipcalc.ErrInvalidSize))
+			}
+			hostSizes = append(hostSizes, size.Uint64())
+		}
+		result, err := ipcalc.Split(ipcalc.NewNetwork(ipcalc.IPToUint32(address), mask1).Network, mask1, hostSizes)
+		if err != nil {
+			return invalid("SIZE", strings.Join(optSplitSizes, ","), err)
+		}
+		split = &result
+	}
+
+	if optCountOnly {
+		return printSubnetCount(w, new(big.Int).SetUint64(ipcalc.SubnetCount(mask1, mask2)))
+	}
+
+	if structuredOutput() {
+		return printReport(w, newIPv4Report(ipcalc.IPToUint32(address), mask1, mask2, split))
+	}
+	if tableOutput() {
+		return printRows(w, ipv4Rows(ipcalc.IPToUint32(address), mask1, mask2, split))
 	}
 
-	addressUint := ipToUint32(address)
-	mask1Uint := cidrToMask(mask1)
-	_ = cidrToMask(mask2)
+	beginTable(w)
+
+	addressUint := ipcalc.IPToUint32(address)
+	mask1Uint := ipcalc.CIDRToMask(mask1)
 
-	printLine("Address", addressUint, mask1Uint, mask1Uint, mask1, mask2, true)
-	printLine("Netmask", mask1Uint, mask1Uint, mask1Uint, mask1, mask2, false)
-	printLine("Wildcard", ^mask1Uint, mask1Uint, mask1Uint, mask1, mask2, false)
+	printLine(w, "Address", addressUint, mask1Uint, mask1Uint, mask1, mask2)
+	printLine(w, "Netmask", mask1Uint, mask1Uint, mask1Uint, mask1, mask2)
+	printLine(w, "Wildcard", ^mask1Uint, mask1Uint, mask1Uint, mask1, mask2)
 
 	if optHTML {
-		fmt.Print("<tr>\n<td colspan=\"3\"><tt>=></tt></td>\n</tr>\n")
+		fmt.Fprint(w, "<tr>\n<td colspan=\"3\">=&gt;</td>\n</tr>\n")
 	} else {
-		fmt.Println("=>")
+		fmt.Fprintln(w, "=>")
 	}
 
-	network := addressUint & mask1Uint
-	printNet(network, mask1Uint, mask1, mask2)
+	n := ipcalc.NewNetwork(addressUint, mask1)
+	network := n.Network
+	printNet(w, n, mask2)
 
-	if optHTML {
-		fmt.Print("</table>\n")
-	}
+	endTable(w)
 
-	if optSplit {
-		splitNetwork(network, mask1, mask2, optSplitSizes)
-		os.Exit(0)
+	if split != nil {
+		splitNetwork(w, mask1, mask2, *split)
+		return nil
 	}
 
 	if mask1 < mask2 {
-		fmt.Printf("Subnets after transition from /%d to /%d\n\n", mask1, mask2)
-		subnets(network, mask1, mask2)
+		printHeading(w, fmt.Sprintf("Subnets after transition from /%d to /%d", mask1, mask2))
+		printBlank(w)
+		subnets(w, network, mask1, mask2)
 	}
 
 	if mask1 > mask2 {
-		fmt.Println("Supernet")
-		supernet(network, mask1, mask2)
-		if optHTML {
-			fmt.Print("</table>\n")
+		printHeading(w, "Supernet")
+		supernet(w, network, mask1, mask2)
+	}
+
+	return nil
+}
+
+var errRangeInverted = &inputError{msg: "ADDRESS2 must not be lower than ADDRESS", err: ipcalc.ErrRangeInverted}
+
+// calculateRange deaggregates the address range from addressStr to
+// address2Str into networks and writes them to w.
+func calculateRange(w io.Writer, addressStr, address2Str string) error {
+	address := net.ParseIP(addressStr)
+	if address == nil {
+		return invalid("ADDRESS", addressStr, ipcalc.ErrInvalidAddress)
+	}
+	address2 := net.ParseIP(address2Str)
+	if address2 == nil {
+		return invalid("ADDRESS2", address2Str, ipcalc.ErrInvalidAddress)
+	}
+
+	// IPv4-mapped addresses are IPv6, as in calculate
+	isIPv6 := address.To4() == nil || strings.Contains(addressStr, ":")
+	if isIPv6 != (address2.To4() == nil || strings.Contains(address2Str, ":")) {
+		return &inputError{msg: "ADDRESS and ADDRESS2 must be of the same family", err: ipcalc.ErrFamilyMismatch}
+	}
+
+	if isIPv6 {
+		if ipcalc.IP6ToBigInt(address).Cmp(ipcalc.IP6ToBigInt(address2)) > 0 {
+			return errRangeInverted
+		}
+		if structuredOutput() {
+			return printReport(w, newRange6Report(address, address2))
 		}
+		if tableOutput() {
+			return printRows(w, slices.Values(range6Rows(ipcalc.Deaggregate6(address, address2))))
+		}
+		printDeaggregate6(w, ipcalc.Deaggregate6(address, address2))
+		return nil
 	}
 
-	if optHTML {
-		printHTMLFooter()
+	if ipcalc.IPToUint32(address) > ipcalc.IPToUint32(address2) {
+		return errRangeInverted
+	}
+
+	if structuredOutput() {
+		return printReport(w, newRangeReport(ipcalc.IPToUint32(address), ipcalc.IPToUint32(address2)))
+	}
+	if tableOutput() {
+		return printRows(w, slices.Values(rangeRows(ipcalc.Deaggregate(ipcalc.IPToUint32(address), ipcalc.IPToUint32(address2)))))
 	}
+	printDeaggregate(w, ipcalc.Deaggregate(ipcalc.IPToUint32(address), ipcalc.IPToUint32(address2)))
+	return nil
 }
@@ -2,10 +2,12 @@ package main
 
 import (
 	"fmt"
-	"net"
+	"html"
+	"io"
 	"os"
-	"strconv"
 	"strings"
+
+	"github.com/stenstromen/goipcalc/ipcalc"
 )
 
 const (
@@ -26,6 +28,16 @@ var (
 	subntColor = colorGreen
 )
 
+// cssClasses names the CSS class that stands in for each color in HTML
+// output.
+var cssClasses = map[string]string{
+	quadsColor: "address",
+	binryColor: "bits",
+	maskColor:  "mask",
+	classColor: "class",
+	subntColor: "subnet",
+}
+
 func setColor(color string) string {
 	if !optColor {
 		return ""
@@ -33,7 +45,93 @@ func setColor(color string) string {
 	return color
 }
 
-func printLine(label string, address uint32, mask1, mask2 uint32, cidr1, cidr2 int, htmlFillup bool) {
+// paint returns text in the given color: between ANSI codes with --color, in
+// a span of the matching CSS class with --html, otherwise unchanged.
+func paint(color, text string) string {
+	if color == normlColor || text == "" {
+		return text
+	}
+	if optHTML {
+		return fmt.Sprintf("<span class=\"%s\">%s</span>", cssClasses[color], html.EscapeString(text))
+	}
+	return setColor(color) + text + setColor(normlColor)
+}
+
+// coloredText builds a string out of pieces in different colors, painting
+// each run of one color at once.
+type coloredText struct {
+	b     strings.Builder
+	run   strings.Builder
+	color string
+}
+
+func (t *coloredText) write(color, text string) {
+	if color != t.color {
+		t.flush()
+		t.color = color
+	}
+	t.run.WriteString(text)
+}
+
+func (t *coloredText) flush() {
+	t.b.WriteString(paint(t.color, t.run.String()))
+	t.run.Reset()
+}
+
+func (t *coloredText) String() string {
+	t.flush()
+	return t.b.String()
+}
+
+// printHeading prints a line introducing the next part of the output.
+func printHeading(w io.Writer, text string) {
+	if optHTML {
+		fmt.Fprintf(w, "<h2>%s</h2>\n", html.EscapeString(text))
+		return
+	}
+	fmt.Fprintln(w, text)
+}
+
+// printText prints a line of text between the tables.
+func printText(w io.Writer, text string) {
+	if optHTML {
+		fmt.Fprintf(w, "<p>%s</p>\n", html.EscapeString(strings.TrimSpace(text)))
+		return
+	}
+	fmt.Fprintln(w, text)
+}
+
+// printBlank separates parts of the text output; HTML needs no spacing.
+func printBlank(w io.Writer) {
+	if !optHTML {
+		fmt.Fprintln(w)
+	}
+}
+
+// beginTable and endTable enclose the rows printed by printLine, printNet and
+// the other row printers in HTML output.
+func beginTable(w io.Writer) {
+	if optHTML {
+		fmt.Fprint(w, "<table class=\"ipcalc\">\n")
+	}
+}
+
+func endTable(w io.Writer) {
+	if optHTML {
+		fmt.Fprint(w, "</table>\n")
+	}
+}
+
+// printCount prints a labelled number, such as the subnet count.
+func printCount(w io.Writer, label, count string) {
+	if optHTML {
+		fmt.Fprintf(w, "<tr>\n<td>%s:</td>\n<td>%s</td>\n</tr>\n", label, paint(quadsColor, count))
+		return
+	}
+	fmt.Fprintf(w, "%-11s%s\n", label+":", paint(quadsColor, count))
+}
+
+func printLine(w io.Writer, label string, address uint32, mask1, mask2 uint32, cidr1, cidr2 int) {
 	additionalInfo := ""
 	if label == "Netmask" {
 		additionalInfo = fmt.Sprintf(" = %d", cidr1)
@@ -42,39 +140,28 @@ func printLine(label string, address uint32, mask1, mask2 uint32, cidr1, cidr2 i
 		additionalInfo = fmt.Sprintf("/%d", cidr1)
 	}
 
-	ipStr := uint32ToIP(address).String() + additionalInfo
+	ipStr := ipcalc.Uint32ToIP(address).String() + additionalInfo
 
 	if optHTML {
-		fmt.Printf("<tr>\n<td><tt>%s:</tt></td>\n", label)
-		if htmlFillup {
-			fmt.Printf("<td><tt>%s", ipStr)
-			for i := len(ipStr); i < 21; i++ {
-				fmt.Print("&nbsp;")
-			}
-			fmt.Print("</tt></td>\n")
-		} else {
-			fmt.Printf("<td><tt>%-21s</tt></td>\n", ipStr)
-		}
+		fmt.Fprintf(w, "<tr>\n<td>%s:</td>\n<td>%s</td>\n", label, paint(quadsColor, ipStr))
 	} else {
-		fmt.Printf("%-11s", label+":")
-		fmt.Print(setColor(quadsColor))
-		fmt.Printf("%-21s", ipStr)
-		fmt.Print(setColor(normlColor))
+		fmt.Fprintf(w, "%-11s", label+":")
+		fmt.Fprint(w, paint(quadsColor, fmt.Sprintf("%-21s", ipStr)))
 	}
 
 	if optPrintBits {
-		printBinary(address, mask1, mask2, cidr1, cidr2, label == "Netmask", label == "Network" || (label == "Hostroute" && cidr1 == 32))
+		printBinary(w, address, mask1, mask2, cidr1, cidr2, label == "Netmask", label == "Network" || (label == "Hostroute" && cidr1 == 32))
 	}
 
 	if optHTML {
-		fmt.Print("</tr>\n")
+		fmt.Fprint(w, "</tr>\n")
 	} else {
-		fmt.Println()
+		fmt.Fprintln(w)
 	}
 }
 
-func printBinary(address, mask1, mask2 uint32, cidr1, cidr2 int, isNetmask, isNetwork bool) {
-	var line strings.Builder
+func printBinary(w io.Writer, address, mask1, mask2 uint32, cidr1, cidr2 int, isNetmask, isNetwork bool) {
+	var line coloredText
 	bitColor := binryColor
 	if isNetmask {
 		bitColor = maskColor
@@ -86,137 +173,76 @@ func printBinary(address, mask1, mask2 uint32, cidr1, cidr2 int, isNetmask, isNe
 	for i := 1; i <= 32; i++ {
 		bit := (address >> (32 - i)) & 1
 
+		color := bitColor
 		if classBitColorOn {
-			line.WriteString(setColor(classColor))
+			color = classColor
 		} else if newBitColorOn {
-			line.WriteString(setColor(subntColor))
-		} else {
-			line.WriteString(setColor(bitColor))
+			color = subntColor
 		}
 
 		if bit == 1 {
-			line.WriteString("1")
+			line.write(color, "1")
 		} else {
-			line.WriteString("0")
+			line.write(color, "0")
 		}
 
 		if classBitColorOn && bit == 0 {
 			classBitColorOn = false
-			if newBitColorOn {
-				line.WriteString(setColor(subntColor))
-			} else {
-				line.WriteString(setColor(bitColor))
-			}
 		}
 
 		if i%8 == 0 && i < 32 {
-			line.WriteString(setColor(normlColor))
-			line.WriteString(".")
-			if classBitColorOn {
-				line.WriteString(setColor(classColor))
-			} else if newBitColorOn {
-				line.WriteString(setColor(subntColor))
-			} else {
-				line.WriteString(setColor(bitColor))
-			}
+			line.write(normlColor, ".")
 		}
 
 		if i == cidr1 {
-			line.WriteString(" ")
+			line.write(normlColor, " ")
 		}
 
 		if (i == cidr1 || i == cidr2) && cidr1 != cidr2 {
-			if newBitColorOn {
-				newBitColorOn = false
-				if !classBitColorOn {
-					line.WriteString(setColor(bitColor))
-				}
-			} else {
-				newBitColorOn = true
-				if !classBitColorOn {
-					line.WriteString(setColor(subntColor))
-				}
-			}
+			newBitColorOn = !newBitColorOn
 		}
 	}
 
-	line.WriteString(setColor(normlColor))
-
 	if optHTML {
-		fmt.Printf("<td><tt>%s</tt></td>\n", line.String())
+		fmt.Fprintf(w, "<td>%s</td>\n", line.String())
 	} else {
-		fmt.Print(" ")
-		fmt.Print(line.String())
+		fmt.Fprint(w, " ")
+		fmt.Fprint(w, line.String())
 	}
 }
 
-func printNet(network, mask uint32, cidr1, cidr2 int) {
-	broadcast := network | (^mask)
-	hmin := network + 1
-	hmax := broadcast - 1
-	hostn := hmax - hmin + 1
-
-	if cidr1 == 31 {
-		hmax = broadcast
-		hmin = network
-		hostn = 2
-	}
-	if cidr1 == 32 {
-		hostn = 1
-	}
-
-	if cidr1 == 32 {
-		printLine("Hostroute", network, mask, mask, cidr1, cidr2, true)
+func printNet(w io.Writer, n ipcalc.Network, cidr2 int) {
+	if n.Prefix == 32 {
+		printLine(w, "Hostroute", n.Network, n.Netmask, n.Netmask, n.Prefix, cidr2)
 	} else {
-		printLine("Network", network, mask, mask, cidr1, cidr2, true)
-		printLine("HostMin", hmin, mask, mask, cidr1, cidr2, false)
-		printLine("HostMax", hmax, mask, mask, cidr1, cidr2, false)
-		if cidr1 < 31 {
-			printLine("Broadcast", broadcast, mask, mask, cidr1, cidr2, false)
+		printLine(w, "Network", n.Network, n.Netmask, n.Netmask, n.Prefix, cidr2)
+		printLine(w, "HostMin", n.HostMin, n.Netmask, n.Netmask, n.Prefix, cidr2)
+		printLine(w, "HostMax", n.HostMax, n.Netmask, n.Netmask, n.Prefix, cidr2)
+		if n.Prefix < 31 {
+			printLine(w, "Broadcast", n.Broadcast, n.Netmask, n.Netmask, n.Prefix, cidr2)
 		}
 	}
 
 	if optHTML {
-		fmt.Print("<tr>\n<td valign=\"top\"><tt>Hosts/Net: </tt></td>\n")
-		fmt.Printf("<td valign=\"top\"><tt>%s%-22s</tt></td>\n", setColor(quadsColor), fmt.Sprintf("%d", hostn))
-		fmt.Print("<td>")
-		fmt.Print(getDescription(network, mask, cidr1))
-		fmt.Print("</td>\n</tr>\n")
+		fmt.Fprint(w, "<tr>\n<td>Hosts/Net:</td>\n")
+		fmt.Fprintf(w, "<td>%s</td>\n", paint(quadsColor, fmt.Sprintf("%d", n.Hosts)))
+		fmt.Fprintf(w, "<td>%s</td>\n</tr>\n", getDescription(n))
 	} else {
-		fmt.Print("Hosts/Net: ")
-		fmt.Print(setColor(quadsColor))
-		fmt.Printf("%-22s", fmt.Sprintf("%d", hostn))
-		fmt.Print(setColor(normlColor))
-		fmt.Println(getDescription(network, mask, cidr1))
-		fmt.Println()
+		fmt.Fprint(w, "Hosts/Net: ")
+		fmt.Fprint(w, paint(quadsColor, fmt.Sprintf("%-22s", fmt.Sprintf("%d", n.Hosts))))
+		fmt.Fprintln(w, getDescription(n))
+		fmt.Fprintln(w)
 	}
 }
 
-func getDescription(network, mask uint32, cidr int) string {
-	var desc []string
-
-	ip := uint32ToIP(network)
-	class := getClass(ip)
-	if optColor || optHTML {
-		if optHTML {
-			desc = append(desc, fmt.Sprintf("<font color=\"#009900\">Class %s</font>", class))
-		} else {
-			desc = append(desc, fmt.Sprintf("%sClass %s%s", setColor(classColor), class, setColor(normlColor)))
-		}
-	} else {
-		desc = append(desc, fmt.Sprintf("Class %s", class))
-	}
+func getDescription(n ipcalc.Network) string {
+	desc := []string{paint(classColor, fmt.Sprintf("Class %s", n.Class))}
 
-	netblockTxt, netblockURL := getNetblock(network, mask)
-	if netblockTxt != "" {
-		if optHTML {
-			desc = append(desc, fmt.Sprintf("<a href=\"%s\">%s</a>", netblockURL, netblockTxt))
-		} else {
-			desc = append(desc, netblockTxt)
-		}
+	if n.Netblock.Name != "" {
+		desc = append(desc, netblockLink(n.Netblock))
 	}
 
-	if cidr == 31 {
+	if n.Prefix == 31 {
 		if optHTML {
 			desc = append(desc, "<a href=\"http://www.ietf.org/rfc/rfc3021.txt\">PtP Link</a>")
 		} else {
@@ -227,45 +253,13 @@ func getDescription(network, mask uint32, cidr int) string {
 	return strings.Join(desc, ", ")
 }
 
-func getNetblock(network, mask uint32) (string, string) {
-	broadcast := network | (^mask)
-
-	netblocks := map[string][]string{
-		"192.168.0.0/16": {"Private Internet", "http://www.ietf.org/rfc/rfc1918.txt"},
-		"172.16.0.0/12":  {"Private Internet", "http://www.ietf.org/rfc/rfc1918.txt"},
-		"10.0.0.0/8":     {"Private Internet", "http://www.ietf.org/rfc/rfc1918.txt"},
-		"169.254.0.0/16": {"APIPA", "http://www.ietf.org/rfc/rfc3330.txt"},
-		"127.0.0.0/8":    {"Loopback", "http://www.ietf.org/rfc/rfc1700.txt"},
-		"224.0.0.0/4":    {"Multicast", "http://www.ietf.org/rfc/rfc3171.txt"},
-	}
-
-	for blockStr, info := range netblocks {
-		parts := strings.Split(blockStr, "/")
-		blockIP := net.ParseIP(parts[0]).To4()
-		blockMask, _ := strconv.Atoi(parts[1])
-		blockStart := ipToUint32(blockIP)
-		blockEnd := blockStart + (uint32(1) << (32 - blockMask)) - 1
-
-		match := 0
-		if network >= blockStart && network <= blockEnd {
-			match++
-		}
-		if broadcast >= blockStart && broadcast <= blockEnd {
-			match++
-		}
-		if blockStart > network && blockEnd < broadcast {
-			match = 1
-		}
-
-		if match == 1 {
-			return "In Part " + info[0], info[1]
-		}
-		if match == 2 {
-			return info[0], info[1]
-		}
+// netblockLink returns the description of b, linked to its RFC in HTML
+// output.
+func netblockLink(b ipcalc.Netblock) string {
+	if optHTML {
+		return fmt.Sprintf("<a href=\"%s\">%s</a>", b.URL, html.EscapeString(b.Description()))
 	}
-
-	return "", ""
+	return b.Description()
 }
 
 func isTerminal(f *os.File) bool {
@@ -276,23 +270,28 @@ func isTerminal(f *os.File) bool {
 	return (stat.Mode() & os.ModeCharDevice) != 0
 }
 
-func printHTMLHeader() {
-	fmt.Print(`<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01 Transitional//EN">
-<html>
+func printHTMLHeader(w io.Writer) {
+	fmt.Fprintf(w, `<!DOCTYPE html>
+<html lang="en">
 <head>
-<meta HTTP-EQUIV="content-type" CONTENT="text/html; charset=UTF-8">
-<title>Bla</title>
+<meta charset="utf-8">
+<meta name="generator" content="ipcalc %s">
+<title>IP Calculator</title>
+<style>
+table.ipcalc { border-collapse: collapse; font-family: monospace; margin-bottom: 1em; }
+table.ipcalc td { padding: 0 1em 0 0; vertical-align: top; white-space: pre; }
+ul.networks { font-family: monospace; list-style: none; padding: 0; }
+.address { color: #0000cc; }
+.bits { color: #998800; }
+.mask { color: #cc0000; }
+.class { color: #aa00aa; }
+.subnet { color: #009900; }
+</style>
 </head>
 <body>
-`)
-	fmt.Printf("<!-- Version %s -->\n", version)
+`, version)
 }
 
-func printHTMLFooter() {
-	fmt.Print(`    <p>
-      <a href="http://validator.w3.org/check/referer"><img border="0"
-          src="http://www.w3.org/Icons/valid-html401"
-          alt="Valid HTML 4.01!" height="31" width="88"></a>
-    </p>
-`)
+func printHTMLFooter(w io.Writer) {
+	fmt.Fprint(w, "</body>\n</html>\n")
 }
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPaint(t *testing.T) {
+	defer func(color, html bool) { optColor, optHTML = color, html }(optColor, optHTML)
+
+	optColor, optHTML = false, false
+	if got := paint(maskColor, "1111"); got != "1111" {
+		t.Errorf("paint without color = %q", got)
+	}
+
+	optColor = true
+	if got := paint(maskColor, "1111"); got != colorRed+"1111"+colorReset {
+		t.Errorf("paint with color = %q", got)
+	}
+
+	optColor, optHTML = false, true
+	if got := paint(maskColor, "a<b"); got != `<span class="mask">a&lt;b</span>` {
+		t.Errorf("paint in HTML = %q", got)
+	}
+	if got := paint(normlColor, "."); got != "." {
+		t.Errorf("paint(normlColor) in HTML = %q", got)
+	}
+}
+
+func TestHTMLOutput(t *testing.T) {
+	defer func(html bool) { optHTML = html }(optHTML)
+	optHTML = true
+
+	for _, args := range []string{
+		"192.168.0.1/24 /26",
+		"192.168.0.1/24 /16",
+		"10.0.0.1 - 10.0.0.9",
+		"2001:db8::/48 /50",
+	} {
+		var buf bytes.Buffer
+		printHTMLHeader(&buf)
+		if err := calculate(&buf, strings.Fields(args)); err != nil {
+			t.Fatalf("calculate(%s): %v", args, err)
+		}
+		printHTMLFooter(&buf)
+		out := buf.String()
+
+		if !strings.HasPrefix(out, "<!DOCTYPE html>\n") || !strings.HasSuffix(out, "</html>\n") {
+			t.Errorf("%s: output is not a complete document", args)
+		}
+		for _, obsolete := range []string{"<font", "<tt>", "\033["} {
+			if strings.Contains(out, obsolete) {
+				t.Errorf("%s: output contains %q", args, obsolete)
+			}
+		}
+		for _, tag := range []string{"table", "tr", "td", "span", "ul", "li", "p", "h2"} {
+			if open, closed := strings.Count(out, "<"+tag+">")+strings.Count(out, "<"+tag+" "), strings.Count(out, "</"+tag+">"); open != closed {
+				t.Errorf("%s: %d <%s> but %d </%s>", args, open, tag, closed, tag)
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stenstromen/goipcalc/ipcalc"
+)
+
+var overlapFile string
+
+var overlapCmd = &cobra.Command{
+	Use:     "overlap [options] [<PREFIX>...]",
+	Aliases: []string{"conflicts"},
+	Short:   "Report overlapping and duplicate networks",
+	Long: `overlap reads a list of IPv4 and IPv6 prefixes, each optionally
+followed by a label, and reports every pair that overlaps: which one
+contains the other, or that they are duplicates. The list is taken
+from the arguments, from a file given with -f, or from stdin, one
+entry per line. A label follows its prefix on the same line; given as
+an argument, the prefix and label are quoted together. The exit
+status is 1 when any overlap is found, and 7 when the list cannot be
+read.`,
+	Example: `  ipcalc overlap 10.0.0.0/16 10.0.1.0/24
+  ipcalc overlap '10.0.0.0/16 vpc-prod' '10.0.1.0/24 office-vpn'
+  ipcalc overlap -f peerings.txt
+  printf '10.0.0.0/16 vpc-prod\n10.0.128.0/17 office-vpn\n' | ipcalc overlap`,
+	RunE: runOverlap,
+}
+
+func init() {
+	overlapCmd.Flags().StringVarP(&overlapFile, "file", "f", "", "Read prefixes from file (- for stdin)")
+	rootCmd.AddCommand(overlapCmd)
+}
+
+func runOverlap(cmd *cobra.Command, args []string) error {
+	lines, err := readInputs(args, overlapFile)
+	if err != nil {
+		return err
+	}
+
+	var prefixes []ipcalc.Prefix
+	var labels []string
+	for _, line := range lines {
+		prefixStr, label := line, ""
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			prefixStr, label = line[:i], strings.TrimSpace(line[i:])
+		}
+		p, err := ipcalc.ParsePrefix(prefixStr)
+		if err != nil {
+			return invalid("PREFIX", prefixStr, err)
+		}
+		prefixes = append(prefixes, p)
+		labels = append(labels, label)
+	}
+
+	overlaps := ipcalc.FindOverlaps(prefixes)
+
+	if structuredOutput() {
+		if err := printReport(os.Stdout, newOverlapReport(prefixes, labels, overlaps)); err != nil {
+			return err
+		}
+	} else {
+		name := func(i int) string {
+			if labels[i] == "" {
+				return prefixes[i].String()
+			}
+			return fmt.Sprintf("%s (%s)", prefixes[i], labels[i])
+		}
+		for _, o := range overlaps {
+			if o.Duplicate {
+				fmt.Printf("%s duplicates %s\n", name(o.Inner), name(o.Outer))
+			} else {
+				fmt.Printf("%s contains %s\n", name(o.Outer), name(o.Inner))
+			}
+		}
+		if len(overlaps) == 0 {
+			fmt.Printf("No overlaps in %d networks\n", len(prefixes))
+		}
+	}
+
+	if len(overlaps) > 0 {
+		return exitStatus(exitFailure)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stenstromen/goipcalc/ipcalc"
+)
+
+var (
+	ptrTemplate   string
+	ptrMaxRecords uint64
+)
+
+var ptrCmd = &cobra.Command{
+	Use:   "ptr -t <TEMPLATE> [options] <NETWORK> | <ADDRESS1> - <ADDRESS2>",
+	Short: "Generate PTR records for the hosts of a network",
+	Long: `ptr prints a BIND zone file fragment with one PTR record for every host
+of a network, HostMin to HostMax, or of an address range. The hostname
+of each record comes from a template in which these placeholders are
+replaced:
+
+  {a} {b} {c} {d}  the octets of an IPv4 address
+  {ip}             the address with dots or colons replaced by dashes
+  {hex}            the address as 8 or 32 hex digits
+
+An IPv6 network contains every address from its first to its last, and
+an IPv4-mapped address such as ::ffff:192.0.2.1 is IPv6, named under
+ip6.arpa. To
+guard against runaway output, ptr refuses to write more records than
+--max-records.`,
+	Example: `  ipcalc ptr -t 'host-{a}-{b}-{c}-{d}.example.net.' 192.0.2.0/28
+  ipcalc ptr -t 'v6-{hex}.example.net.' 2001:db8::/120
+  ipcalc ptr -t '{ip}.pool.example.net.' 10.0.0.10 - 10.0.0.20`,
+	Args: usageArgs(cobra.RangeArgs(1, 3)),
+	RunE: runPTR,
+}
+
+func init() {
+	ptrCmd.Flags().StringVarP(&ptrTemplate, "template", "t", "", "Hostname template of the PTR records")
+	ptrCmd.Flags().Uint64Var(&ptrMaxRecords, "max-records", 65536, "Largest number of records to generate")
+	rootCmd.AddCommand(ptrCmd)
+}
+
+func runPTR(cmd *cobra.Command, args []string) error {
+	if ptrTemplate == "" {
+		return usageError{fmt.Errorf("a hostname template is required (-t)")}
+	}
+
+	var first, last ipcalc.Prefix
+	var name string
+	switch {
+	case len(args) == 3 && args[1] == "-":
+		var err error
+		if first, last, err = parseRangeEnds(args[0], args[2]); err != nil {
+			return err
+		}
+		name = fmt.Sprintf("%s - %s", first.IP, last.IP)
+	case len(args) == 1:
+		p, err := ipcalc.ParsePrefix(args[0])
+		if err != nil {
+			return invalid("NETWORK", args[0], err)
+		}
+		first, last = hostRange(p)
+		name = p.String()
+	default:
+		return usageError{fmt.Errorf("expected NETWORK or ADDRESS1 - ADDRESS2")}
+	}
+
+	count := new(big.Int).Sub(last.First(), first.First())
+	count.Add(count, big.NewInt(1))
+	if count.Cmp(new(big.Int).SetUint64(ptrMaxRecords)) > 0 {
+		return usageError{fmt.Errorf("%s has %s hosts, more than --max-records %d", name, count, ptrMaxRecords)}
+	}
+	if host := ipcalc.ExpandHostname(ptrTemplate, first); strings.ContainsAny(host, "{}") {
+		return usageError{fmt.Errorf("unknown placeholder in template %q for %s", ptrTemplate, name)}
+	}
+
+	if structuredOutput() {
+		return printReport(os.Stdout, newPTRReport(name, first, last, ptrTemplate))
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	printPTRRecords(w, name, first, last, ptrTemplate)
+	return w.Flush()
+}
+
+// hostRange returns the first and last host of p as host prefixes. For
+// IPv4 these are HostMin and HostMax; an IPv6 network has no broadcast
+// address and all of its addresses are hosts.
+func hostRange(p ipcalc.Prefix) (ipcalc.Prefix, ipcalc.Prefix) {
+	if p.Is4() {
+		n := ipcalc.NewNetwork(ipcalc.IPToUint32(p.IP), p.Len)
+		return ipcalc.NewPrefix(ipcalc.Uint32ToIP(n.HostMin), 32), ipcalc.NewPrefix(ipcalc.Uint32ToIP(n.HostMax), 32)
+	}
+	return ipcalc.Prefix{IP: p.IP, Len: 128}, ipcalc.Prefix{IP: ipcalc.BigIntToIP6(p.Last()), Len: 128}
+}
+
+// hostAddresses calls f with every host prefix from first to last.
+func hostAddresses(first, last ipcalc.Prefix, f func(host ipcalc.Prefix)) {
+	one := big.NewInt(1)
+	end := last.First()
+	for n := first.First(); n.Cmp(end) <= 0; n.Add(n, one) {
+		ip := make(net.IP, first.Bits()/8)
+		n.FillBytes(ip)
+		f(ipcalc.Prefix{IP: ip, Len: first.Bits()})
+	}
+}
+
+func printPTRRecords(w io.Writer, name string, first, last ipcalc.Prefix, template string) {
+	fmt.Fprintf(w, "; PTR records for %s\n", name)
+	hostAddresses(first, last, func(host ipcalc.Prefix) {
+		fmt.Fprintf(w, "%s.\tIN\tPTR\t%s\n", ipcalc.ReverseName(host), ipcalc.ExpandHostname(template, host))
+	})
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stenstromen/goipcalc/ipcalc"
+)
+
+func TestPrintPTRRecords(t *testing.T) {
+	tests := []struct {
+		network string
+		hosts   string
+	}{
+		{"192.0.2.0/30", "1.2.0.192 2.2.0.192"},
+		{"192.0.2.4/31", "4.2.0.192 5.2.0.192"},
+		{"192.0.2.9/32", "9.2.0.192"},
+	}
+
+	for _, tt := range tests {
+		p, err := ipcalc.ParsePrefix(tt.network)
+		if err != nil {
+			t.Fatal(err)
+		}
+		first, last := hostRange(p)
+		var buf bytes.Buffer
+		printPTRRecords(&buf, tt.network, first, last, "h{d}.example.net.")
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")[1:]
+		var hosts []string
+		for _, line := range lines {
+			fields := strings.Split(line, "\t")
+			name := strings.TrimSuffix(fields[0], ".in-addr.arpa.")
+			if want := "h" + strings.Split(name, ".")[0] + ".example.net."; fields[3] != want {
+				t.Errorf("%s: %s points to %s, want %s", tt.network, fields[0], fields[3], want)
+			}
+			hosts = append(hosts, name)
+		}
+		if got := strings.Join(hosts, " "); got != tt.hosts {
+			t.Errorf("%s: records for %s, want %s", tt.network, got, tt.hosts)
+		}
+	}
+}
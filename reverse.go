@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stenstromen/goipcalc/ipcalc"
+)
+
+var reverseNS []string
+
+var reverseCmd = &cobra.Command{
+	Use:   "reverse [options] <NETWORK>...",
+	Short: "List the reverse DNS zones of a network",
+	Long: `reverse prints the in-addr.arpa or ip6.arpa zones needed to cover each
+network. Zones fall on octet boundaries for IPv4 and nibble boundaries
+for IPv6, so a network in between needs several of them.
+
+An IPv4 network longer than /24 cannot have a zone of its own under
+in-addr.arpa. For those, reverse prints the RFC 2317 classless zone
+name and the records its parent /24 zone needs to delegate it: a CNAME
+for every address and, given --ns, the NS records of the new zone.`,
+	Example: `  ipcalc reverse 10.20.0.0/22
+  ipcalc reverse 2001:db8:ab00::/39
+  ipcalc reverse --ns ns1.example.net --ns ns2.example.net 192.0.2.64/27`,
+	Args: usageArgs(cobra.MinimumNArgs(1)),
+	RunE: runReverse,
+}
+
+func init() {
+	reverseCmd.Flags().StringSliceVar(&reverseNS, "ns", []string{}, "Name servers of a classless delegation")
+	rootCmd.AddCommand(reverseCmd)
+}
+
+func runReverse(cmd *cobra.Command, args []string) error {
+	var prefixes []ipcalc.Prefix
+	for _, arg := range args {
+		p, err := ipcalc.ParsePrefix(arg)
+		if err != nil {
+			return invalid("NETWORK", arg, err)
+		}
+		prefixes = append(prefixes, p)
+	}
+
+	if structuredOutput() {
+		return printReport(os.Stdout, newReverseReport(prefixes, reverseNS))
+	}
+
+	for i, p := range prefixes {
+		if i > 0 {
+			fmt.Println()
+		}
+		for _, zone := range ipcalc.ReverseZones(p) {
+			fmt.Println(zone)
+		}
+		if d, ok := ipcalc.ClasslessDelegation(p); ok {
+			fmt.Println()
+			fmt.Printf("; RFC 2317 delegation in %s\n", d.Parent)
+			for _, ns := range reverseNS {
+				fmt.Printf("%s.\tIN\tNS\t%s\n", d.Zone, fqdn(ns))
+			}
+			for _, c := range d.CNAMEs {
+				fmt.Printf("%s.\tIN\tCNAME\t%s.\n", c.Name, c.Target)
+			}
+		}
+	}
+	return nil
+}
+
+// fqdn returns name with the trailing dot of an absolute domain name.
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
@@ -2,161 +2,154 @@ package main
 
 import (
 	"fmt"
-	"math/bits"
-)
-
-func subnets(network uint32, mask1, mask2 int) {
-	mask1Uint := cidrToMask(mask1)
-	mask2Uint := cidrToMask(mask2)
-
-	if optHTML {
-		fmt.Print("<table border=\"0\" cellspacing=\"0\" cellpadding=\"0\">\n")
-	}
+	"io"
+	"math/big"
 
-	printLine("Netmask", mask2Uint, mask2Uint, mask1Uint, mask2, mask1, true)
-	printLine("Wildcard", ^mask2Uint, mask2Uint, mask1Uint, mask2, mask1, false)
-
-	if optHTML {
-		fmt.Print("</table>\n")
-	}
+	"github.com/stenstromen/goipcalc/ipcalc"
+)
 
-	fmt.Println()
+var (
+	optOffset    string
+	optLimit     string
+	optCountOnly = false
 
-	subnetCount := 1 << (mask2 - mask1)
-	subnetNum := 0
+	subnetOffset = new(big.Int)
+	subnetLimit  *big.Int
+)
 
-	for subnetNum < subnetCount && subnetNum < 1000 {
-		net := network | (uint32(subnetNum) << (32 - mask2))
-		fmt.Printf(" %d.\n", subnetNum+1)
-		if optHTML {
-			fmt.Print("<table border=\"0\" cellspacing=\"0\" cellpadding=\"0\">\n")
-		}
-		printNet(net, mask2Uint, mask2, mask1)
-		if optHTML {
-			fmt.Print("</table>\n")
+// parsePaging parses --offset and --limit, which accept the same forms as
+// split sizes.
+func parsePaging() error {
+	if optOffset != "" {
+		n, err := ipcalc.ParseSize(optOffset)
+		if err != nil {
+			return invalid("OFFSET", optOffset, err)
 		}
-		subnetNum++
+		subnetOffset = n
 	}
-
-	if subnetNum >= 1000 {
-		if optHTML {
-			fmt.Print("... stopped at 1000 subnets ...<br>\n")
-		} else {
-			fmt.Println("... stopped at 1000 subnets ...")
+	if optLimit != "" {
+		n, err := ipcalc.ParseSize(optLimit)
+		if err != nil {
+			return invalid("LIMIT", optLimit, err)
 		}
+		subnetLimit = n
 	}
+	return nil
+}
 
-	hostn := (network | (^mask2Uint)) - network - 1
-	if hostn < 1 {
-		hostn = 1
+// subnetWindow returns the zero based indices of the first subnet to list
+// and of the one after the last, out of count subnets, as selected by
+// --offset and --limit.
+func subnetWindow(count *big.Int) (first, end *big.Int) {
+	first = new(big.Int).Set(subnetOffset)
+	if first.Cmp(count) > 0 {
+		first.Set(count)
+	}
+	end = new(big.Int).Set(count)
+	if subnetLimit != nil {
+		if e := new(big.Int).Add(first, subnetLimit); e.Cmp(end) < 0 {
+			end = e
+		}
 	}
+	return first, end
+}
 
-	if optHTML {
-		fmt.Printf("\nSubnets:   <font color=\"#0000ff\">%d</font><br>\n", subnetCount)
-		fmt.Printf("Hosts:     <font color=\"#0000ff\">%d</font><br>\n", uint64(hostn)*uint64(subnetCount))
-	} else {
-		fmt.Printf("\nSubnets:   %s%d%s\n", setColor(quadsColor), subnetCount, setColor(normlColor))
-		fmt.Printf("Hosts:     %s%d%s\n", setColor(quadsColor), uint64(hostn)*uint64(subnetCount), setColor(normlColor))
+// printSubnetCount prints the number of subnets alone, for --count-only.
+func printSubnetCount(w io.Writer, count *big.Int) error {
+	if structuredOutput() {
+		return printReport(w, countReport{Count: (*bigNumber)(count)})
 	}
+	printText(w, count.String())
+	return nil
 }
 
-func supernet(network uint32, mask1, mask2 int) {
-	mask2Uint := cidrToMask(mask2)
-	network = network & mask2Uint
+// subnetWindow4 is subnetWindow for IPv4 subnet counts.
+func subnetWindow4(count uint64) (first, end uint64) {
+	f, e := subnetWindow(new(big.Int).SetUint64(count))
+	return f.Uint64(), e.Uint64()
+}
 
-	printLine("Netmask", mask2Uint, mask2Uint, cidrToMask(mask1), mask2, mask1, true)
-	printLine("Wildcard", ^mask2Uint, mask2Uint, cidrToMask(mask1), mask2, mask1, false)
+func subnets(w io.Writer, network uint32, mask1, mask2 int) {
+	mask1Uint := ipcalc.CIDRToMask(mask1)
+	mask2Uint := ipcalc.CIDRToMask(mask2)
 
-	fmt.Println()
+	beginTable(w)
+	printLine(w, "Netmask", mask2Uint, mask2Uint, mask1Uint, mask2, mask1)
+	printLine(w, "Wildcard", ^mask2Uint, mask2Uint, mask1Uint, mask2, mask1)
+	endTable(w)
 
-	printNet(network, mask2Uint, mask2, mask1)
-}
+	printBlank(w)
 
-func splitNetwork(network uint32, mask1, mask2 int, sizes []int) {
-	mask1Uint := cidrToMask(mask1)
+	subnetCount := ipcalc.SubnetCount(mask1, mask2)
+	first, end := subnetWindow4(subnetCount)
 
-	type netInfo struct {
-		size int
-		net  uint32
-		mask int
+	for i := first; i < end; i++ {
+		printText(w, fmt.Sprintf(" %d.", i+1))
+		beginTable(w)
+		printNet(w, ipcalc.Subnet(network, mask2, i), mask1)
+		endTable(w)
 	}
 
-	var nets []netInfo
-	neededAddresses := 0
-
-	for _, size := range sizes {
-		neededSize := round2PowerOf2(size + 2)
-		nets = append(nets, netInfo{
-			size: neededSize,
-			net:  0,
-			mask: size2BitCountMask(neededSize),
-		})
-		neededAddresses += neededSize
-	}
+	hosts := ipcalc.SubnetHosts(mask1, mask2)
 
-	// Sort by size descending
-	for k := 0; k < len(nets)-1; k++ {
-		for j := k + 1; j < len(nets); j++ {
-			if nets[k].size < nets[j].size {
-				nets[k], nets[j] = nets[j], nets[k]
-			}
-		}
-	}
+	printBlank(w)
+	beginTable(w)
+	printCount(w, "Subnets", fmt.Sprint(subnetCount))
+	printCount(w, "Hosts", fmt.Sprint(hosts))
+	endTable(w)
+}
 
-	currentNet := network
-	for i := range nets {
-		nets[i].net = currentNet
-		currentNet += uint32(nets[i].size)
-	}
+func supernet(w io.Writer, network uint32, mask1, mask2 int) {
+	mask2Uint := ipcalc.CIDRToMask(mask2)
 
-	for i, size := range sizes {
-		fmt.Printf("%d. Requested size: %d hosts\n", i+1, size)
-		printLine("Netmask", cidrToMask(nets[i].mask), cidrToMask(nets[i].mask), mask1Uint, nets[i].mask, mask2, false)
-		printNet(nets[i].net, cidrToMask(nets[i].mask), nets[i].mask, mask2)
-	}
+	beginTable(w)
+	printLine(w, "Netmask", mask2Uint, mask2Uint, ipcalc.CIDRToMask(mask1), mask2, mask1)
+	printLine(w, "Wildcard", ^mask2Uint, mask2Uint, ipcalc.CIDRToMask(mask1), mask2, mask1)
+	endTable(w)
 
-	usedMask := size2BitCountMask(neededAddresses)
-	if usedMask < mask1 {
-		fmt.Println("Network is too small")
-	}
+	printBlank(w)
 
-	fmt.Printf("Needed size:  %d addresses.\n", neededAddresses)
-	fmt.Printf("Used network: %s/%d\n", uint32ToIP(network).String(), usedMask)
-	fmt.Println("Unused:")
-	broadcast := network | (^mask1Uint)
-	deaggregate(currentNet, broadcast)
+	beginTable(w)
+	printNet(w, ipcalc.Supernet(network, mask2), mask1)
+	endTable(w)
 }
 
-func round2PowerOf2(n int) int {
-	if n <= 0 {
-		return 1
+func splitNetwork(w io.Writer, mask1, mask2 int, result ipcalc.SplitResult) {
+	mask1Uint := ipcalc.CIDRToMask(mask1)
+
+	for i, a := range result.Allocations {
+		printHeading(w, fmt.Sprintf("%d. Requested size: %d hosts", i+1, a.Requested))
+		beginTable(w)
+		printLine(w, "Netmask", a.Network.Netmask, a.Network.Netmask, mask1Uint, a.Network.Prefix, mask2)
+		printNet(w, a.Network, mask2)
+		endTable(w)
 	}
-	return 1 << bits.Len(uint(n-1))
+
+	printText(w, fmt.Sprintf("Needed size:  %d addresses.", result.Needed))
+	printText(w, fmt.Sprintf("Used network: %s/%d", ipcalc.Uint32ToIP(result.Used.Address).String(), result.Used.Prefix))
+	printText(w, "Unused:")
+	printDeaggregate(w, result.Unused)
 }
 
-func size2BitCountMask(size int) int {
-	if size <= 0 {
-		return 32
+func printDeaggregate(w io.Writer, nets []ipcalc.Network) {
+	var prefixes []string
+	for _, n := range nets {
+		prefixes = append(prefixes, fmt.Sprintf("%s/%d", ipcalc.Uint32ToIP(n.Network).String(), n.Prefix))
 	}
-	return 32 - bits.Len(uint(size-1))
+	printNetworks(w, prefixes)
 }
 
-func deaggregate(start, end uint32) {
-	base := start
-	for base <= end {
-		step := 0
-		for step < 32 {
-			test := base | (uint32(1) << step)
-			if test != base {
-				break
-			}
-			mask := ^(uint32(1)<<(32-step) - 1)
-			if (base | mask) > end {
-				break
-			}
-			step++
+// printNetworks prints a list of networks in CIDR notation, one per line.
+func printNetworks(w io.Writer, prefixes []string) {
+	if !optHTML {
+		for _, p := range prefixes {
+			fmt.Fprintln(w, p)
 		}
-		fmt.Printf("%s/%d\n", uint32ToIP(base).String(), 32-step)
-		base += uint32(1) << step
+		return
+	}
+	fmt.Fprint(w, "<ul class=\"networks\">\n")
+	for _, p := range prefixes {
+		fmt.Fprintf(w, "<li>%s</li>\n", p)
 	}
+	fmt.Fprint(w, "</ul>\n")
 }
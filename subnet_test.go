@@ -1,259 +1,116 @@
 package main
 
 import (
+	"bytes"
+	"errors"
+	"iter"
+	"math/big"
 	"net"
-	"strconv"
+	"slices"
 	"strings"
 	"testing"
-)
 
-func TestRound2PowerOf2(t *testing.T) {
-	tests := []struct {
-		name     string
-		input    int
-		expected int
-	}{
-		{"Zero", 0, 1},
-		{"One", 1, 1},
-		{"Two", 2, 2},
-		{"Three", 3, 4},
-		{"Four", 4, 4},
-		{"Five", 5, 8},
-		{"Seven", 7, 8},
-		{"Eight", 8, 8},
-		{"Nine", 9, 16},
-		{"Fifteen", 15, 16},
-		{"Sixteen", 16, 16},
-		{"Seventeen", 17, 32},
-		{"Thirty one", 31, 32},
-		{"Thirty two", 32, 32},
-		{"Negative", -1, 1},
-		{"Large number", 100, 128},
-		{"Very large", 1000, 1024},
-	}
+	"github.com/stenstromen/goipcalc/ipcalc"
+)
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := round2PowerOf2(tt.input)
-			if result != tt.expected {
-				t.Errorf("round2PowerOf2(%d) = %d, want %d", tt.input, result, tt.expected)
-			}
-		})
-	}
-}
+func TestSubnetWindow(t *testing.T) {
+	defer func() { subnetOffset, subnetLimit = new(big.Int), nil }()
 
-func TestSize2BitCountMask(t *testing.T) {
 	tests := []struct {
-		name     string
-		size     int
-		expected int
+		offset, limit string
+		count         int64
+		first, end    int64
 	}{
-		{"Size 1", 1, 32}, // 1 address needs /32
-		{"Size 2", 2, 31},
-		{"Size 3", 3, 30},
-		{"Size 4", 4, 30},
-		{"Size 8", 8, 29},
-		{"Size 16", 16, 28},
-		{"Size 32", 32, 27},
-		{"Size 64", 64, 26},
-		{"Size 128", 128, 25},
-		{"Size 256", 256, 24},
-		{"Size 512", 512, 23},
-		{"Size 1024", 1024, 22},
-		{"Zero", 0, 32},
-		{"Negative", -1, 32},
+		{"", "", 4096, 0, 4096},
+		{"1000", "", 4096, 1000, 4096},
+		{"", "10", 4096, 0, 10},
+		{"4090", "10", 4096, 4090, 4096},
+		{"5000", "10", 4096, 4096, 4096},
+		{"2^10", "0", 4096, 1024, 1024},
 	}
 
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := size2BitCountMask(tt.size)
-			if result != tt.expected {
-				t.Errorf("size2BitCountMask(%d) = %d, want %d", tt.size, result, tt.expected)
-			}
-		})
-	}
-}
-
-func deaggregateToSlice(start, end uint32) []string {
-	var results []string
-	base := start
-	for base <= end {
-		step := 0
-		for step < 32 {
-			test := base | (uint32(1) << step)
-			if test != base {
-				break
-			}
-			mask := ^(uint32(1)<<(32-step) - 1)
-			if (base | mask) > end {
-				break
-			}
-			step++
+		subnetOffset, subnetLimit = new(big.Int), nil
+		optOffset, optLimit = tt.offset, tt.limit
+		if err := parsePaging(); err != nil {
+			t.Fatalf("parsePaging(%q, %q): %v", tt.offset, tt.limit, err)
+		}
+		first, end := subnetWindow(big.NewInt(tt.count))
+		if first.Int64() != tt.first || end.Int64() != tt.end {
+			t.Errorf("offset %q limit %q of %d: got [%s, %s), want [%d, %d)", tt.offset, tt.limit, tt.count, first, end, tt.first, tt.end)
 		}
-		results = append(results, uint32ToIP(base).String()+"/"+strconv.Itoa(32-step))
-		base += uint32(1) << step
 	}
-	return results
+	optOffset, optLimit = "", ""
 }
 
-func TestDeaggregate(t *testing.T) {
-	tests := []struct {
-		name          string
-		start         string
-		end           string
-		expectedCount int // Just verify we get reasonable number of results
-		minCount      int
-		maxCount      int
-	}{
-		{
-			name:          "Single IP",
-			start:         "192.168.0.1",
-			end:           "192.168.0.1",
-			expectedCount: 1,
-			minCount:      1,
-			maxCount:      1,
-		},
-		{
-			name:          "Two IPs",
-			start:         "192.168.0.1",
-			end:           "192.168.0.2",
-			expectedCount: 1,
-			minCount:      1,
-			maxCount:      1,
-		},
-		{
-			name:          "Small range",
-			start:         "192.168.0.1",
-			end:           "192.168.0.10",
-			expectedCount: 3,
-			minCount:      3,
-			maxCount:      3,
-		},
-		{
-			name:          "Aligned /30",
-			start:         "192.168.0.0",
-			end:           "192.168.0.3",
-			expectedCount: 1,
-			minCount:      1,
-			maxCount:      3, // May produce multiple if not perfectly aligned
-		},
-		{
-			name:          "Aligned /28",
-			start:         "192.168.0.0",
-			end:           "192.168.0.15",
-			expectedCount: 1,
-			minCount:      1,
-			maxCount:      5, // May produce multiple if not perfectly aligned
-		},
+func TestSubnetsReportUncapped(t *testing.T) {
+	r := newSubnetsReport(ipcalc.IPToUint32(net.ParseIP("10.0.0.0")), 16, 28)
+	if n := len(slices.Collect(iter.Seq[networkReport](r.Networks))); r.Count != 4096 || n != 4096 || r.Truncated {
+		t.Errorf("/16 to /28: count %d, %d networks, truncated %v; want all 4096", r.Count, n, r.Truncated)
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			startIP := net.ParseIP(tt.start).To4()
-			endIP := net.ParseIP(tt.end).To4()
-			start := ipToUint32(startIP)
-			end := ipToUint32(endIP)
+	defer func() { subnetLimit = nil }()
+	subnetLimit = big.NewInt(3)
+	r6 := newSubnets6Report(net.ParseIP("2001:db8::"), 32, 128)
+	want, _ := new(big.Int).SetString("79228162514264337593543950336", 10)
+	if n := len(slices.Collect(iter.Seq[network6Report](r6.Networks))); (*big.Int)(r6.Count).Cmp(want) != 0 || n != 3 || !r6.Truncated {
+		t.Errorf("/32 to /128 with limit 3: count %s, %d networks, truncated %v", r6.Count, n, r6.Truncated)
+	}
+}
 
-			results := deaggregateToSlice(start, end)
+// errShortWriter is returned by shortWriter once its space is used up.
+var errShortWriter = errors.New("short write")
 
-			if len(results) < tt.minCount || len(results) > tt.maxCount {
-				t.Errorf("deaggregate(%s, %s) returned %d results, want between %d and %d", tt.start, tt.end, len(results), tt.minCount, tt.maxCount)
-				t.Errorf("Got: %v", results)
-			}
+// shortWriter accepts n bytes and fails after that.
+type shortWriter struct{ n int }
 
-			// Verify all results are valid CIDR notation
-			for i, result := range results {
-				parts := strings.Split(result, "/")
-				if len(parts) != 2 {
-					t.Errorf("deaggregate(%s, %s)[%d] = %s, invalid format", tt.start, tt.end, i, result)
-				}
-				if net.ParseIP(parts[0]) == nil {
-					t.Errorf("deaggregate(%s, %s)[%d] = %s, invalid IP", tt.start, tt.end, i, result)
-				}
-				cidr, err := strconv.Atoi(parts[1])
-				if err != nil || cidr < 0 || cidr > 32 {
-					t.Errorf("deaggregate(%s, %s)[%d] = %s, invalid CIDR", tt.start, tt.end, i, result)
-				}
-			}
-		})
+func (w *shortWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		n := w.n
+		w.n = 0
+		return n, errShortWriter
 	}
+	w.n -= len(p)
+	return len(p), nil
 }
 
-func TestDeaggregateRange(t *testing.T) {
-	// Test that deaggregate produces valid ranges (simplified test)
-	testCases := []struct {
-		name  string
-		start string
-		end   string
-	}{
-		{"Small range", "192.168.0.1", "192.168.0.10"},
-		{"Medium range", "192.168.0.1", "192.168.0.100"},
-		{"Aligned range", "192.168.0.0", "192.168.0.255"},
+func TestSubnetListingStreamed(t *testing.T) {
+	defer func() {
+		optJSON, optCSV, optTemplate, subnetLimit = false, false, nil, nil
+	}()
+	tmpl, err := parseFormat("{{range .Subnets.Networks}}{{.Network}}\n{{end}}")
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	for _, tt := range testCases {
-		t.Run(tt.name, func(t *testing.T) {
-			startIP := net.ParseIP(tt.start).To4()
-			endIP := net.ParseIP(tt.end).To4()
-			start := ipToUint32(startIP)
-			end := ipToUint32(endIP)
-
-			results := deaggregateToSlice(start, end)
-
-			// Verify we got some results
-			if len(results) == 0 {
-				t.Errorf("deaggregate(%s, %s) returned no results", tt.start, tt.end)
-				return
+	outputs := []struct {
+		name string
+		set  func()
+	}{
+		{"json", func() { optJSON = true }},
+		{"csv", func() { optCSV = true }},
+		{"format", func() { optTemplate = tmpl }},
+	}
+	for _, args := range []string{"10.0.0.0/8 /32", "2001:db8::/48 /128"} {
+		for _, o := range outputs {
+			optJSON, optCSV, optTemplate = false, false, nil
+			o.set()
+
+			// A bounded window of a huge listing is small
+			subnetLimit = big.NewInt(5)
+			var buf bytes.Buffer
+			if err := calculate(&buf, strings.Fields(args)); err != nil {
+				t.Fatalf("%s %s --limit 5: %v", o.name, args, err)
 			}
-
-			// Verify all results are valid CIDR notation
-			for i, result := range results {
-				parts := strings.Split(result, "/")
-				if len(parts) != 2 {
-					t.Errorf("deaggregate(%s, %s)[%d] = %s, invalid format", tt.start, tt.end, i, result)
-					continue
-				}
-				if net.ParseIP(parts[0]) == nil {
-					t.Errorf("deaggregate(%s, %s)[%d] = %s, invalid IP", tt.start, tt.end, i, result)
-				}
-				cidr, err := strconv.Atoi(parts[1])
-				if err != nil || cidr < 0 || cidr > 32 {
-					t.Errorf("deaggregate(%s, %s)[%d] = %s, invalid CIDR", tt.start, tt.end, i, result)
-				}
+			if buf.Len() > 4096 {
+				t.Errorf("%s %s --limit 5: %d bytes of output", o.name, args, buf.Len())
 			}
 
-			// Verify ranges are in order and don't go beyond end
-			for i, result := range results {
-				parts := strings.Split(result, "/")
-				networkIP := net.ParseIP(parts[0]).To4()
-				if networkIP == nil {
-					continue // Already tested above
-				}
-				network := ipToUint32(networkIP)
-				cidr, _ := strconv.Atoi(parts[1])
-				mask := cidrToMask(cidr)
-				broadcast := network | (^mask)
-
-				if network < start {
-					t.Errorf("Range %s starts before start address %s", result, tt.start)
-				}
-				if broadcast > end {
-					t.Errorf("Range %s extends beyond end address %s", result, tt.end)
-				}
-				if i > 0 {
-					prevParts := strings.Split(results[i-1], "/")
-					prevIP := net.ParseIP(prevParts[0]).To4()
-					if prevIP != nil {
-						prevCIDR, _ := strconv.Atoi(prevParts[1])
-						prevMask := cidrToMask(prevCIDR)
-						prevBroadcast := ipToUint32(prevIP) | (^prevMask)
-						if network <= prevBroadcast {
-							t.Errorf("Range %s overlaps or is out of order with previous range %s", result, results[i-1])
-						}
-					}
-				}
+			// Without a limit, output starts at once and stops with the writer
+			subnetLimit = nil
+			if err := calculate(&shortWriter{n: 1 << 16}, strings.Fields(args)); !errors.Is(err, errShortWriter) {
+				t.Errorf("%s %s into a full writer: error = %v, want a write error", o.name, args, err)
 			}
-		})
+		}
 	}
 }
-